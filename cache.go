@@ -0,0 +1,325 @@
+package go_tilaa
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	catalogPresets   = "presets"
+	catalogTemplates = "templates"
+	catalogSites     = "sites"
+)
+
+// CatalogCache caches the presets, templates and sites catalogs, which rarely change, for the given TTL. Entries are
+// kept in memory and, when Directory is set, on disk so they survive between invocations of short lived programs.
+// It is safe for concurrent use and may be shared between clients.
+type CatalogCache struct {
+	TTL       time.Duration
+	Directory string
+
+	mutex   sync.Mutex
+	entries map[string]catalogEntry
+}
+
+type catalogEntry struct {
+	Expires time.Time       `json:"expires"`
+	Data    json.RawMessage `json:"data"`
+}
+
+type CachedPresetService struct {
+	service PresetServiceInterface
+	cache   *CatalogCache
+	client  *Client
+}
+
+type CachedTemplateService struct {
+	service TemplateServiceInterface
+	cache   *CatalogCache
+	client  *Client
+}
+
+type CachedSiteService struct {
+	service SiteServiceInterface
+	cache   *CatalogCache
+	client  *Client
+}
+
+var _ PresetServiceInterface = &CachedPresetService{}
+var _ TemplateServiceInterface = &CachedTemplateService{}
+var _ SiteServiceInterface = &CachedSiteService{}
+
+func NewCatalogCache(ttl time.Duration) *CatalogCache {
+	return &CatalogCache{TTL: ttl, entries: map[string]catalogEntry{}}
+}
+
+// NewDiskCatalogCache creates a CatalogCache which also persists its entries in the given directory. An empty
+// directory defaults to a go-tilaa directory in the user's cache directory.
+func NewDiskCatalogCache(ttl time.Duration, directory string) (*CatalogCache, error) {
+	if directory == "" {
+		cacheDirectory, err := os.UserCacheDir()
+
+		if err != nil {
+			return nil, NewClientError(err.Error())
+		}
+
+		directory = filepath.Join(cacheDirectory, "go-tilaa")
+	}
+
+	cache := NewCatalogCache(ttl)
+	cache.Directory = directory
+
+	return cache, nil
+}
+
+// WithCatalogCache serves the Preset, Template and Site services from the given cache. The services are wrapped after
+// all options are applied, so services set through WithPresetService and friends are cached regardless of the order
+// of the options.
+func WithCatalogCache(cache *CatalogCache) Option {
+	return func(client *Client) error {
+		client.catalogCache = cache
+
+		return nil
+	}
+}
+
+// wrapCatalogServices puts the catalog services of the client behind its CatalogCache, if it has one.
+func (client *Client) wrapCatalogServices() {
+	if client.catalogCache == nil {
+		return
+	}
+
+	client.Preset = &CachedPresetService{service: client.Preset, cache: client.catalogCache, client: client}
+	client.Template = &CachedTemplateService{service: client.Template, cache: client.catalogCache, client: client}
+	client.Site = &CachedSiteService{service: client.Site, cache: client.catalogCache, client: client}
+}
+
+// InvalidateCatalogCache drops the cached catalogs of the client, if it has a CatalogCache.
+func (client *Client) InvalidateCatalogCache() {
+	if client.catalogCache != nil {
+		client.catalogCache.Invalidate()
+	}
+}
+
+// Invalidate drops the given catalogs ("presets", "templates" or "sites") from the cache, or every catalog when none
+// are given.
+func (cache *CatalogCache) Invalidate(catalogs ...string) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	for key := range cache.entries {
+		if len(catalogs) == 0 || containsCatalog(catalogs, key) {
+			delete(cache.entries, key)
+		}
+	}
+
+	if cache.Directory == "" {
+		return
+	}
+
+	files, _ := filepath.Glob(filepath.Join(cache.Directory, "*.json"))
+
+	for _, file := range files {
+		if len(catalogs) == 0 || containsCatalog(catalogs, strings.TrimSuffix(filepath.Base(file), ".json")) {
+			_ = os.Remove(file)
+		}
+	}
+}
+
+func (cache *CatalogCache) get(key string, result interface{}) bool {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if cache.entries == nil {
+		cache.entries = map[string]catalogEntry{}
+	}
+
+	entry, ok := cache.entries[key]
+
+	if !ok && cache.Directory != "" {
+		entry, ok = cache.readFile(key)
+
+		if ok {
+			cache.entries[key] = entry
+		}
+	}
+
+	if !ok || time.Now().After(entry.Expires) {
+		return false
+	}
+
+	return json.Unmarshal(entry.Data, result) == nil
+}
+
+func (cache *CatalogCache) set(key string, value interface{}) {
+	data, err := json.Marshal(value)
+
+	if err != nil {
+		return
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if cache.entries == nil {
+		cache.entries = map[string]catalogEntry{}
+	}
+
+	entry := catalogEntry{Expires: time.Now().Add(cache.TTL), Data: data}
+
+	cache.entries[key] = entry
+
+	if cache.Directory != "" {
+		cache.writeFile(key, entry)
+	}
+}
+
+func (cache *CatalogCache) readFile(key string) (catalogEntry, bool) {
+	var entry catalogEntry
+
+	contents, err := ioutil.ReadFile(cache.path(key))
+
+	if err != nil {
+		return entry, false
+	}
+
+	return entry, json.Unmarshal(contents, &entry) == nil
+}
+
+// writeFile persists the entry on a best effort basis, failing to write the cache must never fail a request.
+func (cache *CatalogCache) writeFile(key string, entry catalogEntry) {
+	contents, err := json.Marshal(entry)
+
+	if err != nil {
+		return
+	}
+
+	if err := os.MkdirAll(cache.Directory, 0700); err != nil {
+		return
+	}
+
+	temporary, err := ioutil.TempFile(cache.Directory, ".catalog-*")
+
+	if err != nil {
+		return
+	}
+
+	_, err = temporary.Write(contents)
+
+	if closeErr := temporary.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = os.Remove(temporary.Name())
+
+		return
+	}
+
+	if err := os.Rename(temporary.Name(), cache.path(key)); err != nil {
+		_ = os.Remove(temporary.Name())
+	}
+}
+
+func (cache *CatalogCache) path(key string) string {
+	return filepath.Join(cache.Directory, key+".json")
+}
+
+// cacheKey scopes the catalog to the API endpoint, so a disk cache shared between endpoints stays consistent.
+func cacheKey(client *Client, catalog string) string {
+	host := strings.NewReplacer(":", "_", "/", "_").Replace(client.BaseUrl.Host)
+
+	return host + "_" + client.ApiVersion + "_" + catalog
+}
+
+func containsCatalog(catalogs []string, key string) bool {
+	for _, catalog := range catalogs {
+		if key == catalog || strings.HasSuffix(key, "_"+catalog) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (service *CachedPresetService) List() (*Presets, error) {
+	return service.ListWithContext(context.Background())
+}
+
+func (service *CachedPresetService) ListWithContext(ctx context.Context) (*Presets, error) {
+	key := cacheKey(service.client, catalogPresets)
+
+	var presets Presets
+
+	if service.cache.get(key, &presets) {
+		presets.client = service.client
+
+		return &presets, nil
+	}
+
+	result, err := service.service.ListWithContext(ctx)
+
+	if err == nil {
+		service.cache.set(key, result)
+	}
+
+	return result, err
+}
+
+func (service *CachedTemplateService) List() (*[]Template, error) {
+	return service.ListWithContext(context.Background())
+}
+
+func (service *CachedTemplateService) ListWithContext(ctx context.Context) (*[]Template, error) {
+	key := cacheKey(service.client, catalogTemplates)
+
+	var templates []Template
+
+	if service.cache.get(key, &templates) {
+		for i := range templates {
+			templates[i].client = service.client
+		}
+
+		return &templates, nil
+	}
+
+	result, err := service.service.ListWithContext(ctx)
+
+	if err == nil {
+		service.cache.set(key, result)
+	}
+
+	return result, err
+}
+
+func (service *CachedSiteService) List() (*[]Site, error) {
+	return service.ListWithContext(context.Background())
+}
+
+func (service *CachedSiteService) ListWithContext(ctx context.Context) (*[]Site, error) {
+	key := cacheKey(service.client, catalogSites)
+
+	var sites []Site
+
+	if service.cache.get(key, &sites) {
+		for i := range sites {
+			sites[i].client = service.client
+		}
+
+		return &sites, nil
+	}
+
+	result, err := service.service.ListWithContext(ctx)
+
+	if err == nil {
+		service.cache.set(key, result)
+	}
+
+	return result, err
+}
@@ -0,0 +1,254 @@
+package go_tilaa_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+	"github.com/pascal-splotches/go-tilaa/tilaamock"
+	"github.com/pascal-splotches/go-tilaa/tilaatest"
+)
+
+// newCachedClient creates a client of the server using the cache, counting the requests it sends.
+func newCachedClient(t *testing.T, server *tilaatest.Server, cache *go_tilaa.CatalogCache) (*go_tilaa.Client, *countingTransport) {
+	transport := &countingTransport{counts: map[string]int{}}
+	client, err := server.Client(go_tilaa.WithHttpClient(&http.Client{Transport: transport}), go_tilaa.WithCatalogCache(cache))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client, transport
+}
+
+func listSites(t *testing.T, client *go_tilaa.Client) []go_tilaa.Site {
+	sites, err := client.Site.ListWithContext(context.Background())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return *sites
+}
+
+func TestCatalogCacheServesFromMemory(t *testing.T) {
+	server := tilaatest.NewServer()
+	defer server.Close()
+
+	client, transport := newCachedClient(t, server, go_tilaa.NewCatalogCache(time.Hour))
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.Preset.List(); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := client.Template.List(); err != nil {
+			t.Fatal(err)
+		}
+
+		listSites(t, client)
+	}
+
+	for _, path := range []string{"GET v1/presets", "GET v1/templates", "GET v1/sites"} {
+		if transport.counts[path] != 1 {
+			t.Errorf("expected %s once, got %d", path, transport.counts[path])
+		}
+	}
+
+	templates, err := client.Template.List()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*templates) != 3 || (*templates)[0].Name != "Ubuntu 20.04" {
+		t.Errorf("unexpected cached templates %+v", *templates)
+	}
+}
+
+func TestCatalogCacheExpires(t *testing.T) {
+	server := tilaatest.NewServer()
+	defer server.Close()
+
+	client, transport := newCachedClient(t, server, go_tilaa.NewCatalogCache(20*time.Millisecond))
+
+	listSites(t, client)
+	listSites(t, client)
+
+	time.Sleep(40 * time.Millisecond)
+
+	server.SetSites([]go_tilaa.Site{{Id: 3, Name: "AMS3"}})
+
+	if sites := listSites(t, client); len(sites) != 1 || sites[0].Name != "AMS3" {
+		t.Errorf("expected the expired catalog to be fetched again, got %+v", sites)
+	}
+
+	if transport.counts["GET v1/sites"] != 2 {
+		t.Errorf("expected 2 requests, got %d", transport.counts["GET v1/sites"])
+	}
+}
+
+func TestCatalogCacheInvalidate(t *testing.T) {
+	server := tilaatest.NewServer()
+	defer server.Close()
+
+	client, transport := newCachedClient(t, server, go_tilaa.NewCatalogCache(time.Hour))
+
+	listSites(t, client)
+
+	if _, err := client.Preset.List(); err != nil {
+		t.Fatal(err)
+	}
+
+	client.InvalidateCatalogCache()
+	listSites(t, client)
+
+	if _, err := client.Preset.List(); err != nil {
+		t.Fatal(err)
+	}
+
+	if transport.counts["GET v1/sites"] != 2 || transport.counts["GET v1/presets"] != 2 {
+		t.Errorf("expected every catalog to be fetched again, got %v", transport.counts)
+	}
+}
+
+func TestCatalogCacheConcurrentUse(t *testing.T) {
+	server := tilaatest.NewServer()
+	defer server.Close()
+
+	cache := go_tilaa.NewCatalogCache(time.Hour)
+	client, _ := newCachedClient(t, server, cache)
+
+	var group sync.WaitGroup
+
+	for i := 0; i < 20; i++ {
+		group.Add(1)
+
+		go func(i int) {
+			defer group.Done()
+
+			if i%5 == 0 {
+				cache.Invalidate("sites")
+			}
+
+			if sites, err := client.Site.List(); err != nil || len(*sites) != 2 {
+				t.Errorf("unexpected sites %v and %v", sites, err)
+			}
+		}(i)
+	}
+
+	group.Wait()
+}
+
+func TestDiskCatalogCache(t *testing.T) {
+	directory, err := ioutil.TempDir("", "catalog")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(directory)
+
+	server := tilaatest.NewServer()
+	defer server.Close()
+
+	cache, err := go_tilaa.NewDiskCatalogCache(time.Hour, directory)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, _ := newCachedClient(t, server, cache)
+	listSites(t, client)
+
+	// A new cache on the same directory, as used by the next invocation of a program, reads the entry from disk
+	cache, err = go_tilaa.NewDiskCatalogCache(time.Hour, directory)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, transport := newCachedClient(t, server, cache)
+
+	if sites := listSites(t, client); len(sites) != 2 || transport.counts["GET v1/sites"] != 0 {
+		t.Errorf("expected the sites to be read from disk, got %+v after %d requests", sites, transport.counts["GET v1/sites"])
+	}
+
+	cache.Invalidate("sites")
+
+	cache, err = go_tilaa.NewDiskCatalogCache(time.Hour, directory)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, transport = newCachedClient(t, server, cache)
+	listSites(t, client)
+
+	if transport.counts["GET v1/sites"] != 1 {
+		t.Error("expected invalidating to remove the entry from disk")
+	}
+}
+
+func TestCatalogCacheIsScopedToTheHost(t *testing.T) {
+	first := tilaatest.NewServer()
+	defer first.Close()
+
+	second := tilaatest.NewServer()
+	defer second.Close()
+
+	second.SetSites([]go_tilaa.Site{{Id: 3, Name: "AMS3"}})
+
+	cache := go_tilaa.NewCatalogCache(time.Hour)
+	firstClient, _ := newCachedClient(t, first, cache)
+	secondClient, _ := newCachedClient(t, second, cache)
+
+	listSites(t, firstClient)
+
+	if sites := listSites(t, secondClient); len(sites) != 1 || sites[0].Name != "AMS3" {
+		t.Errorf("expected the sites of the second server, got %+v", sites)
+	}
+
+	if sites := listSites(t, firstClient); len(sites) != 2 {
+		t.Errorf("expected the sites of the first server, got %+v", sites)
+	}
+}
+
+func TestWithCatalogCacheIsIndependentOfOptionOrder(t *testing.T) {
+	services := tilaamock.NewServices()
+	cache := go_tilaa.NewCatalogCache(time.Hour)
+
+	client, err := go_tilaa.NewWithOptions(
+		go_tilaa.WithCatalogCache(cache),
+		go_tilaa.WithPresetService(services.Preset),
+		go_tilaa.WithTemplateService(services.Template),
+		go_tilaa.WithSiteService(services.Site),
+	)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Preset.List(); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := client.Template.List(); err != nil {
+			t.Fatal(err)
+		}
+
+		listSites(t, client)
+	}
+
+	for _, recorder := range []*tilaamock.Recorder{&services.Preset.Recorder, &services.Template.Recorder, &services.Site.Recorder} {
+		if count := recorder.CallCount("List"); count != 1 {
+			t.Errorf("expected the service set after the cache to be cached, got %d calls", count)
+		}
+	}
+}
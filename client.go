@@ -1,12 +1,14 @@
 package go_tilaa
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 )
 
 const (
@@ -29,10 +31,21 @@ var (
 
 type Client struct {
 	BaseUrl     *url.URL
+	ApiVersion  string
 	UserAgent   string
 	Credentials BasicAuth
+	RetryPolicy RetryPolicy
 
-	httpClient *http.Client
+	CredentialsProvider CredentialsProvider
+
+	httpClient  *http.Client
+	timeout     *time.Duration
+	rateLimiter *RateLimiter
+	inFlight    chan struct{}
+
+	catalogCache *CatalogCache
+
+	snapshotWaiter *Waiter
 
 	VirtualMachine VirtualMachineServiceInterface
 	Snapshot       SnapshotServiceInterface
@@ -49,16 +62,44 @@ type BasicAuth struct {
 }
 
 func New(username string, password string) *Client {
-	return createClient(username, password)
+	client, _ := NewWithOptions(WithBasicAuth(username, password))
+
+	return client
 }
 
+func NewWithOptions(options ...Option) (*Client, error) {
+	client := createClient()
+
+	for _, option := range options {
+		if err := option(client); err != nil {
+			return nil, err
+		}
+	}
+
+	if client.timeout != nil {
+		httpClient := *client.httpClient
+		httpClient.Timeout = *client.timeout
+		client.httpClient = &httpClient
+	}
+
+	client.wrapCatalogServices()
+
+	return client, nil
+}
+
+// SetBasicAuth sets static credentials, replacing any configured CredentialsProvider.
 func (client *Client) SetBasicAuth(username string, password string) {
 	client.Credentials.UserName = username
 	client.Credentials.Password = password
+	client.CredentialsProvider = nil
 }
 
 func (client *Client) Get(path string, result interface{}) (*http.Response, error) {
-	request, err := client.newRequest(http.MethodGet, path, nil, "")
+	return client.GetWithContext(context.Background(), path, result)
+}
+
+func (client *Client) GetWithContext(ctx context.Context, path string, result interface{}) (*http.Response, error) {
+	request, err := client.newRequest(ctx, http.MethodGet, path, nil, "")
 
 	if err != nil {
 		return nil, err
@@ -68,7 +109,11 @@ func (client *Client) Get(path string, result interface{}) (*http.Response, erro
 }
 
 func (client *Client) Post(path string, formData *url.Values, result interface{}) (*http.Response, error) {
-	request, err := client.newRequest(http.MethodPost, path, strings.NewReader(formData.Encode()), ContentTypeFormUrlEncoded)
+	return client.PostWithContext(context.Background(), path, formData, result)
+}
+
+func (client *Client) PostWithContext(ctx context.Context, path string, formData *url.Values, result interface{}) (*http.Response, error) {
+	request, err := client.newRequest(ctx, http.MethodPost, path, strings.NewReader(formData.Encode()), ContentTypeFormUrlEncoded)
 
 	if err != nil {
 		return nil, err
@@ -78,7 +123,11 @@ func (client *Client) Post(path string, formData *url.Values, result interface{}
 }
 
 func (client *Client) Delete(path string, result interface{}) (*http.Response, error) {
-	request, err := client.newRequest(http.MethodDelete, path, nil, "")
+	return client.DeleteWithContext(context.Background(), path, result)
+}
+
+func (client *Client) DeleteWithContext(ctx context.Context, path string, result interface{}) (*http.Response, error) {
+	request, err := client.newRequest(ctx, http.MethodDelete, path, nil, "")
 
 	if err != nil {
 		return nil, err
@@ -87,16 +136,16 @@ func (client *Client) Delete(path string, result interface{}) (*http.Response, e
 	return client.do(request, result)
 }
 
-func (client *Client) newRequest(method string, path string, body io.Reader, contentType string) (*http.Request, error) {
-	path = fmt.Sprintf("%s/%s", ApiVersion, path)
+func (client *Client) newRequest(ctx context.Context, method string, path string, body io.Reader, contentType string) (*http.Request, error) {
+	path = fmt.Sprintf("%s/%s", client.ApiVersion, path)
 
 	relativePath := &url.URL{Path: path}
 	requestUrl := client.BaseUrl.ResolveReference(relativePath)
 
-	request, err := http.NewRequest(method, requestUrl.String(), body)
+	request, err := http.NewRequestWithContext(ctx, method, requestUrl.String(), body)
 
 	if err != nil {
-		return nil, NewApiRequestError(err.Error())
+		return nil, WrapApiRequestError(err)
 	}
 
 	request.Header.Set(headerUserAgentKey, client.UserAgent)
@@ -106,26 +155,44 @@ func (client *Client) newRequest(method string, path string, body io.Reader, con
 		request.Header.Set(contentTypeKey, contentType)
 	}
 
-	request.SetBasicAuth(client.Credentials.UserName, client.Credentials.Password)
+	credentials, err := client.credentials(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	request.SetBasicAuth(credentials.UserName, credentials.Password)
 
 	return request, nil
 }
 
 func (client *Client) do(request *http.Request, result interface{}) (*http.Response, error) {
-	response, err := client.httpClient.Do(request)
+	response, err := client.send(request)
 
 	if err != nil {
-		return nil, NewApiRequestError(err.Error())
+		return nil, WrapApiRequestError(err)
 	}
 
 	defer response.Body.Close()
 
-	if response.StatusCode == http.StatusUnauthorized {
-		return nil, NewInvalidCredentialsError(client.Credentials)
-	}
-
 	if response.StatusCode != http.StatusOK {
-		return nil, NewApiRequestError(fmt.Sprintf("[%s] Request returned with non-200 status", response.Status))
+		responseError := NewApiResponseError(request, response)
+
+		if response.StatusCode == http.StatusUnauthorized {
+			username, _, _ := request.BasicAuth()
+
+			// Rejected credentials are read again on the next request, they may have been corrected in the meantime
+			if provider, ok := client.CredentialsProvider.(*CachingCredentialsProvider); ok {
+				provider.Expire()
+			}
+
+			credentialsError := NewInvalidCredentialsError(BasicAuth{UserName: username})
+			credentialsError.response = responseError
+
+			return nil, credentialsError
+		}
+
+		return nil, responseError
 	}
 
 	err = json.NewDecoder(response.Body).Decode(result)
@@ -137,6 +204,38 @@ func (client *Client) do(request *http.Request, result interface{}) (*http.Respo
 	return response, err
 }
 
+func (client *Client) send(request *http.Request) (*http.Response, error) {
+	ctx := request.Context()
+	policy := client.RetryPolicy
+	attempts := policy.attempts(request.Method)
+
+	for attempt := 1; ; attempt++ {
+		response, err := client.roundTrip(request)
+
+		if attempt >= attempts || !policy.shouldRetry(ctx, response, err) {
+			return response, err
+		}
+
+		delay := policy.backoff(attempt, response)
+
+		discardResponse(response)
+
+		if err := sleepWithContext(ctx, delay); err != nil {
+			return nil, err
+		}
+
+		if request.GetBody != nil {
+			body, err := request.GetBody()
+
+			if err != nil {
+				return nil, err
+			}
+
+			request.Body = body
+		}
+	}
+}
+
 type ResponseStatus string
 
 const (
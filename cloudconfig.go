@@ -0,0 +1,254 @@
+package go_tilaa
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+
+	"gopkg.in/yaml.v2"
+)
+
+// CloudConfigHeader is the first line of cloud-config user data.
+const CloudConfigHeader = "#cloud-config"
+
+// CloudConfig builds cloud-config user data for Metadata:
+//
+//	userData, err := go_tilaa.NewCloudConfig().
+//		WithHostname("web1").
+//		WithUser(go_tilaa.CloudConfigUser{Name: "deploy", Sudo: "ALL=(ALL) NOPASSWD:ALL", SshAuthorizedKeys: keys}).
+//		WithPackages("nginx").
+//		WithFile(go_tilaa.CloudConfigFile{Path: "/etc/motd", Content: "Managed by go-tilaa\n"}).
+//		WithRunCmd("systemctl enable --now nginx").
+//		Render()
+type CloudConfig struct {
+	Hostname          string               `yaml:"hostname,omitempty"`
+	Users             []CloudConfigUser    `yaml:"users,omitempty"`
+	SshAuthorizedKeys []string             `yaml:"ssh_authorized_keys,omitempty"`
+	PackageUpdate     bool                 `yaml:"package_update,omitempty"`
+	PackageUpgrade    bool                 `yaml:"package_upgrade,omitempty"`
+	Packages          []string             `yaml:"packages,omitempty"`
+	WriteFiles        []CloudConfigFile    `yaml:"write_files,omitempty"`
+	BootCmd           []CloudConfigCommand `yaml:"bootcmd,omitempty"`
+	RunCmd            []CloudConfigCommand `yaml:"runcmd,omitempty"`
+}
+
+// CloudConfigUser is an entry of users. A user named "default" without any other fields keeps the default user of
+// the image, which is otherwise not created when users are listed.
+type CloudConfigUser struct {
+	Name              string   `yaml:"name"`
+	Gecos             string   `yaml:"gecos,omitempty"`
+	Groups            []string `yaml:"groups,omitempty,flow"`
+	Shell             string   `yaml:"shell,omitempty"`
+	Sudo              string   `yaml:"sudo,omitempty"`
+	LockPasswd        *bool    `yaml:"lock_passwd,omitempty"`
+	SshAuthorizedKeys []string `yaml:"ssh_authorized_keys,omitempty"`
+}
+
+// CloudConfigFile is an entry of write_files. Permissions are octal, e.g. "0644".
+type CloudConfigFile struct {
+	Path        string `yaml:"path"`
+	Content     string `yaml:"content,omitempty"`
+	Encoding    string `yaml:"encoding,omitempty"`
+	Owner       string `yaml:"owner,omitempty"`
+	Permissions string `yaml:"permissions,omitempty"`
+	Append      bool   `yaml:"append,omitempty"`
+}
+
+// CloudConfigCommand is an entry of runcmd or bootcmd: a command run by the shell, or a list of arguments which is
+// executed directly when Args is set.
+type CloudConfigCommand struct {
+	Shell string
+	Args  []string
+}
+
+// cloudConfigFileEncodings lists the encodings of write_files accepted by cloud-init.
+var cloudConfigFileEncodings = map[string]bool{
+	"":            true,
+	"b64":         true,
+	"base64":      true,
+	"gz":          true,
+	"gzip":        true,
+	"gz+b64":      true,
+	"gz+base64":   true,
+	"gzip+b64":    true,
+	"gzip+base64": true,
+	"text/plain":  true,
+}
+
+func NewCloudConfig() *CloudConfig {
+	return &CloudConfig{}
+}
+
+func (config *CloudConfig) WithHostname(hostname string) *CloudConfig {
+	config.Hostname = hostname
+
+	return config
+}
+
+func (config *CloudConfig) WithUser(user CloudConfigUser) *CloudConfig {
+	config.Users = append(config.Users, user)
+
+	return config
+}
+
+// WithDefaultUser keeps the default user of the image next to the users added through WithUser.
+func (config *CloudConfig) WithDefaultUser() *CloudConfig {
+	return config.WithUser(CloudConfigUser{Name: "default"})
+}
+
+// WithSshAuthorizedKeys adds keys to the default user.
+func (config *CloudConfig) WithSshAuthorizedKeys(keys ...string) *CloudConfig {
+	config.SshAuthorizedKeys = append(config.SshAuthorizedKeys, keys...)
+
+	return config
+}
+
+func (config *CloudConfig) WithPackages(packages ...string) *CloudConfig {
+	config.Packages = append(config.Packages, packages...)
+
+	return config
+}
+
+// WithPackageUpgrade updates the package database and upgrades all packages on first boot.
+func (config *CloudConfig) WithPackageUpgrade() *CloudConfig {
+	config.PackageUpdate = true
+	config.PackageUpgrade = true
+
+	return config
+}
+
+func (config *CloudConfig) WithFile(file CloudConfigFile) *CloudConfig {
+	config.WriteFiles = append(config.WriteFiles, file)
+
+	return config
+}
+
+// WithRunCmd adds shell commands run once on first boot, after the packages are installed.
+func (config *CloudConfig) WithRunCmd(commands ...string) *CloudConfig {
+	for _, command := range commands {
+		config.RunCmd = append(config.RunCmd, CloudConfigCommand{Shell: command})
+	}
+
+	return config
+}
+
+// WithRunCmdArgs adds a command run once on first boot, executed without a shell.
+func (config *CloudConfig) WithRunCmdArgs(args ...string) *CloudConfig {
+	config.RunCmd = append(config.RunCmd, CloudConfigCommand{Args: args})
+
+	return config
+}
+
+// WithBootCmd adds shell commands run early on every boot.
+func (config *CloudConfig) WithBootCmd(commands ...string) *CloudConfig {
+	for _, command := range commands {
+		config.BootCmd = append(config.BootCmd, CloudConfigCommand{Shell: command})
+	}
+
+	return config
+}
+
+// WithBootCmdArgs adds a command run early on every boot, executed without a shell.
+func (config *CloudConfig) WithBootCmdArgs(args ...string) *CloudConfig {
+	config.BootCmd = append(config.BootCmd, CloudConfigCommand{Args: args})
+
+	return config
+}
+
+// Validate checks the users, keys, files and commands of the config and returns a ValidationError listing every
+// problem found.
+func (config *CloudConfig) Validate() error {
+	validationError := NewValidationError()
+
+	for i, user := range config.Users {
+		field := fmt.Sprintf("users[%d]", i)
+
+		if user.Name == "" {
+			validationError.Add(field+".name", "can not be empty")
+		}
+
+		validateAuthorizedKeys(validationError, field+".ssh_authorized_keys", user.SshAuthorizedKeys)
+	}
+
+	validateAuthorizedKeys(validationError, "ssh_authorized_keys", config.SshAuthorizedKeys)
+
+	for i, file := range config.WriteFiles {
+		field := fmt.Sprintf("write_files[%d]", i)
+
+		if !path.IsAbs(file.Path) {
+			validationError.Add(field+".path", "must be absolute, got %q", file.Path)
+		}
+
+		if !cloudConfigFileEncodings[file.Encoding] {
+			validationError.Add(field+".encoding", "unknown encoding %q", file.Encoding)
+		}
+
+		if _, err := strconv.ParseUint(file.Permissions, 8, 32); file.Permissions != "" && err != nil {
+			validationError.Add(field+".permissions", "%q is not an octal mode", file.Permissions)
+		}
+	}
+
+	validateCommands(validationError, "bootcmd", config.BootCmd)
+	validateCommands(validationError, "runcmd", config.RunCmd)
+
+	if validationError.HasErrors() {
+		return validationError
+	}
+
+	return nil
+}
+
+// Render validates the config and returns it as user data, starting with CloudConfigHeader.
+func (config *CloudConfig) Render() (string, error) {
+	if err := config.Validate(); err != nil {
+		return "", err
+	}
+
+	encoded, err := yaml.Marshal(config)
+
+	if err != nil {
+		return "", err
+	}
+
+	return CloudConfigHeader + "\n" + string(encoded), nil
+}
+
+// MarshalYAML writes the default user as the plain string cloud-init expects.
+func (user CloudConfigUser) MarshalYAML() (interface{}, error) {
+	if user.isDefault() {
+		return user.Name, nil
+	}
+
+	type plain CloudConfigUser
+
+	return plain(user), nil
+}
+
+func (user CloudConfigUser) isDefault() bool {
+	return user.Name == "default" && user.Gecos == "" && len(user.Groups) == 0 && user.Shell == "" &&
+		user.Sudo == "" && user.LockPasswd == nil && len(user.SshAuthorizedKeys) == 0
+}
+
+func (command CloudConfigCommand) MarshalYAML() (interface{}, error) {
+	if command.Args != nil {
+		return command.Args, nil
+	}
+
+	return command.Shell, nil
+}
+
+func validateAuthorizedKeys(validationError *ValidationError, field string, keys []string) {
+	for i, key := range keys {
+		if _, err := ParseAuthorizedKey(key); err != nil {
+			validationError.Add(fmt.Sprintf("%s[%d]", field, i), "%s", publicKeyErrorReason(err))
+		}
+	}
+}
+
+func validateCommands(validationError *ValidationError, field string, commands []CloudConfigCommand) {
+	for i, command := range commands {
+		if command.Shell == "" && len(command.Args) == 0 {
+			validationError.Add(fmt.Sprintf("%s[%d]", field, i), "can not be empty")
+		}
+	}
+}
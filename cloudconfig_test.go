@@ -0,0 +1,126 @@
+package go_tilaa
+
+import (
+	"errors"
+	"testing"
+)
+
+const testAuthorizedKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIDI8+ijWxnDjW8Ci9gcxno3sAgHH/KsvjbSKxLEahOAS me@host"
+
+func TestCloudConfigRender(t *testing.T) {
+	lockPasswd := true
+
+	userData, err := NewCloudConfig().
+		WithHostname("web1").
+		WithDefaultUser().
+		WithUser(CloudConfigUser{Name: "deploy", Groups: []string{"sudo", "adm"}, LockPasswd: &lockPasswd, SshAuthorizedKeys: []string{testAuthorizedKey}}).
+		WithPackageUpgrade().
+		WithPackages("nginx").
+		WithFile(CloudConfigFile{Path: "/etc/motd", Content: "hello\n", Permissions: "0644"}).
+		WithBootCmd("echo boot").
+		WithRunCmd("systemctl enable --now nginx").
+		WithRunCmdArgs("touch", "/tmp/done").
+		Render()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `#cloud-config
+hostname: web1
+users:
+- default
+- name: deploy
+  groups: [sudo, adm]
+  lock_passwd: true
+  ssh_authorized_keys:
+  - ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIDI8+ijWxnDjW8Ci9gcxno3sAgHH/KsvjbSKxLEahOAS
+    me@host
+package_update: true
+package_upgrade: true
+packages:
+- nginx
+write_files:
+- path: /etc/motd
+  content: |
+    hello
+  permissions: "0644"
+bootcmd:
+- echo boot
+runcmd:
+- systemctl enable --now nginx
+- - touch
+  - /tmp/done
+`
+
+	if userData != expected {
+		t.Errorf("unexpected user data:\n%s", userData)
+	}
+
+	if err := ValidateUserData(userData); err != nil {
+		t.Errorf("rendered user data does not validate: %v", err)
+	}
+}
+
+func TestCloudConfigUserMarshalYAML(t *testing.T) {
+	tests := []struct {
+		user    CloudConfigUser
+		isPlain bool
+	}{
+		{CloudConfigUser{Name: "default"}, true},
+		{CloudConfigUser{Name: "default", Shell: "/bin/zsh"}, false},
+		{CloudConfigUser{Name: "deploy"}, false},
+	}
+
+	for _, test := range tests {
+		value, err := test.user.MarshalYAML()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, isString := value.(string); isString != test.isPlain {
+			t.Errorf("%+v marshalled to %#v", test.user, value)
+		}
+	}
+}
+
+func TestCloudConfigValidate(t *testing.T) {
+	config := NewCloudConfig().
+		WithUser(CloudConfigUser{SshAuthorizedKeys: []string{"not a key"}}).
+		WithSshAuthorizedKeys(testAuthorizedKey, "ssh-rsa").
+		WithFile(CloudConfigFile{Path: "etc/motd", Encoding: "rot13", Permissions: "0999"}).
+		WithRunCmd("").
+		WithBootCmdArgs()
+
+	err := config.Validate()
+
+	var validationError *ValidationError
+
+	if !errors.As(err, &validationError) {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+
+	for _, field := range []string{
+		"users[0].name",
+		"users[0].ssh_authorized_keys[0]",
+		"ssh_authorized_keys[1]",
+		"write_files[0].path",
+		"write_files[0].encoding",
+		"write_files[0].permissions",
+		"runcmd[0]",
+		"bootcmd[0]",
+	} {
+		if len(validationError.Field(field)) != 1 {
+			t.Errorf("expected an error for %s, got %v", field, validationError)
+		}
+	}
+
+	if len(validationError.Errors) != 8 {
+		t.Errorf("expected 8 errors, got %v", validationError)
+	}
+
+	if _, err := config.Render(); err == nil {
+		t.Error("expected Render to validate the config")
+	}
+}
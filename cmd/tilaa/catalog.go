@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func runPresets(ctx context.Context, app *app, args []string) error {
+	flags := newFlagSet("presets")
+	options := addOutputFlags(flags)
+
+	if _, err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	presets, err := app.client.Preset.ListWithContext(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	rows := [][]string{}
+
+	for _, size := range presets.RamSizes() {
+		rows = append(rows, []string{"ram", "", strconv.Itoa(size)})
+	}
+
+	for _, storageType := range presets.StorageTypes() {
+		for _, size := range presets.StorageSizes(storageType) {
+			rows = append(rows, []string{"storage", string(storageType), strconv.Itoa(size)})
+		}
+	}
+
+	if options.format == outputTable || options.format == "" {
+		rows = [][]string{{"ram", "", joinSizes(presets.RamSizes(), "MB")}}
+
+		for _, storageType := range presets.StorageTypes() {
+			rows = append(rows, []string{"storage", string(storageType), joinSizes(presets.StorageSizes(storageType), "GB")})
+		}
+	}
+
+	return app.print(options, output{columns: presetColumns, rows: rows, records: presets})
+}
+
+func runTemplates(ctx context.Context, app *app, args []string) error {
+	flags := newFlagSet("templates")
+	options := addOutputFlags(flags)
+
+	if _, err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	templates, err := app.client.Template.ListWithContext(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, len(*templates))
+
+	for i, template := range *templates {
+		rows[i] = []string{strconv.Itoa(template.Id), template.Name, strconv.Itoa(template.Ram), strconv.Itoa(template.Storage)}
+	}
+
+	return app.print(options, output{columns: templateColumns, rows: rows, records: emptySlice(*templates)})
+}
+
+func runSites(ctx context.Context, app *app, args []string) error {
+	flags := newFlagSet("sites")
+	options := addOutputFlags(flags)
+
+	if _, err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	sites, err := app.client.Site.ListWithContext(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, len(*sites))
+
+	for i, site := range *sites {
+		rows[i] = []string{strconv.Itoa(site.Id), site.Name}
+	}
+
+	return app.print(options, output{columns: siteColumns, rows: rows, records: emptySlice(*sites)})
+}
+
+var presetColumns = []column{
+	{"resource", "RESOURCE"},
+	{"type", "TYPE"},
+	{"sizes", "SIZES"},
+}
+
+var templateColumns = []column{
+	{"id", "ID"},
+	{"name", "NAME"},
+	{"ram", "MIN RAM"},
+	{"storage", "MIN STORAGE"},
+}
+
+var siteColumns = []column{
+	{"id", "ID"},
+	{"name", "NAME"},
+}
+
+func joinSizes(sizes []int, unit string) string {
+	parts := make([]string, len(sizes))
+
+	for i, size := range sizes {
+		parts[i] = fmt.Sprintf("%d %s", size, unit)
+	}
+
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// intList is a repeatable integer flag, also accepting comma separated values.
+type intList []int
+
+func (list *intList) String() string {
+	values := make([]string, len(*list))
+
+	for i, value := range *list {
+		values[i] = strconv.Itoa(value)
+	}
+
+	return strings.Join(values, ",")
+}
+
+func (list *intList) Set(value string) error {
+	for _, part := range strings.Split(value, ",") {
+		number, err := strconv.Atoi(strings.TrimSpace(part))
+
+		if err != nil {
+			return err
+		}
+
+		*list = append(*list, number)
+	}
+
+	return nil
+}
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (list *stringList) String() string {
+	return strings.Join(*list, ",")
+}
+
+func (list *stringList) Set(value string) error {
+	*list = append(*list, value)
+
+	return nil
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+
+	return flags
+}
+
+// parseFlags parses flags which may be mixed with positional arguments, returning the positional arguments.
+func parseFlags(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+
+		args = flags.Args()
+
+		if len(args) == 0 {
+			return positional, nil
+		}
+
+		if args[0] == "--" {
+			return append(positional, args[1:]...), nil
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// parseId parses the positional argument at the given index as a resource ID.
+func parseId(args []string, index int, name string) (int, error) {
+	if len(args) <= index {
+		return 0, newUsageError("missing %s", name)
+	}
+
+	id, err := strconv.Atoi(args[index])
+
+	if err != nil || id <= 0 {
+		return 0, newUsageError("invalid %s %q", name, args[index])
+	}
+
+	return id, nil
+}
+
+// readInput reads the contents of a file, or of standard input when the path is "-".
+func readInput(path string) (string, error) {
+	if path == "-" {
+		contents, err := ioutil.ReadAll(bufio.NewReader(os.Stdin))
+
+		return string(contents), err
+	}
+
+	contents, err := ioutil.ReadFile(path)
+
+	return string(contents), err
+}
+
+// confirm asks for confirmation on the terminal unless yes is set.
+func confirm(app *app, yes bool, format string, args ...interface{}) error {
+	if yes {
+		return nil
+	}
+
+	fmt.Fprintf(app.stderr, format+" [y/N] ", args...)
+
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+
+	if answer != "y" && answer != "yes" {
+		return fmt.Errorf("aborted")
+	}
+
+	return nil
+}
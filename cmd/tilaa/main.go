@@ -0,0 +1,255 @@
+// Command tilaa manages Tilaa virtual machines, snapshots, SSH keys and metadata from the command line.
+//
+// Credentials are read from the TILAA_USERNAME and TILAA_PASSWORD environment variables, a profile in
+// ~/.tilaa/credentials or ~/.netrc, in that order. Use --profile to select a credentials profile.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+)
+
+const catalogCacheTtl = time.Hour
+
+// command is a single subcommand, such as "vm list".
+type command struct {
+	name        string
+	usage       string
+	description string
+	run         func(ctx context.Context, app *app, args []string) error
+}
+
+type app struct {
+	client *go_tilaa.Client
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// usageError is printed together with the usage of the command which returned it.
+type usageError struct {
+	reason string
+}
+
+func (err *usageError) Error() string {
+	return err.reason
+}
+
+func newUsageError(format string, args ...interface{}) error {
+	return &usageError{reason: fmt.Sprintf(format, args...)}
+}
+
+var groups = map[string][]command{
+	"vm":       vmCommands,
+	"snapshot": snapshotCommands,
+	"sshkey":   sshKeyCommands,
+	"metadata": metadataCommands,
+}
+
+var topLevel = []command{
+	{name: "presets", description: "List the available RAM and storage sizes", run: runPresets},
+	{name: "templates", description: "List the available templates", run: runTemplates},
+	{name: "sites", description: "List the available sites", run: runSites},
+	{name: "version", description: "Print the version", run: runVersion},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(arguments []string) int {
+	flags := flag.NewFlagSet("tilaa", flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+
+	profile := flags.String("profile", "", "credentials profile to use from ~/.tilaa/credentials")
+	timeout := flags.Duration("timeout", 5*time.Minute, "timeout of the whole command")
+	baseUrl := flags.String("base-url", go_tilaa.BaseUrl, "base URL of the Tilaa API")
+	noCache := flags.Bool("no-cache", false, "do not cache presets, templates and sites on disk")
+
+	flags.Usage = func() { printUsage(os.Stderr, flags) }
+
+	if err := flags.Parse(arguments); err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+
+		return 2
+	}
+
+	client, err := newClient(*profile, *baseUrl, !*noCache)
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tilaa: %s\n", err)
+
+		return 1
+	}
+
+	app := &app{client: client, stdout: os.Stdout, stderr: os.Stderr}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	ctx, stop := withInterrupt(ctx)
+	defer stop()
+
+	name, args := flags.Arg(0), flags.Args()[1:]
+
+	cmd, args, ok := findCommand(name, args)
+
+	if !ok {
+		fmt.Fprintf(os.Stderr, "tilaa: unknown command %q\n\n", strings.TrimSpace(name+" "+strings.Join(firstArg(args), "")))
+		flags.Usage()
+
+		return 2
+	}
+
+	if err := cmd.run(ctx, app, args); err != nil {
+		var usage *usageError
+
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+
+		fmt.Fprintf(os.Stderr, "tilaa: %s\n", err)
+
+		if errors.As(err, &usage) {
+			fmt.Fprintf(os.Stderr, "usage: tilaa %s %s\n", cmd.name, cmd.usage)
+
+			return 2
+		}
+
+		return 1
+	}
+
+	return 0
+}
+
+func newClient(profile string, baseUrl string, cache bool) (*go_tilaa.Client, error) {
+	var provider go_tilaa.CredentialsProvider = go_tilaa.DefaultCredentialsProvider()
+
+	if profile != "" {
+		provider = go_tilaa.NewConfigFileCredentialsProvider("", profile)
+	}
+
+	options := []go_tilaa.Option{
+		go_tilaa.WithCredentialsProvider(go_tilaa.NewCachingCredentialsProvider(provider, go_tilaa.DefaultCredentialsTtl)),
+		go_tilaa.WithBaseUrl(baseUrl),
+		go_tilaa.WithUserAgentSuffix("tilaa-cli/" + go_tilaa.Version),
+	}
+
+	if cache {
+		catalogCache, err := go_tilaa.NewDiskCatalogCache(catalogCacheTtl, "")
+
+		if err == nil {
+			options = append(options, go_tilaa.WithCatalogCache(catalogCache))
+		}
+	}
+
+	return go_tilaa.NewWithOptions(options...)
+}
+
+// findCommand resolves "vm list" style group commands as well as top level commands.
+func findCommand(name string, args []string) (command, []string, bool) {
+	if commands, ok := groups[name]; ok {
+		if len(args) == 0 {
+			return command{}, args, false
+		}
+
+		for _, cmd := range commands {
+			if cmd.name == args[0] {
+				cmd.name = name + " " + cmd.name
+
+				return cmd, args[1:], true
+			}
+		}
+
+		return command{}, args, false
+	}
+
+	for _, cmd := range topLevel {
+		if cmd.name == name {
+			return cmd, args, true
+		}
+	}
+
+	return command{}, args, false
+}
+
+func printUsage(output io.Writer, flags *flag.FlagSet) {
+	fmt.Fprintln(output, "usage: tilaa [global flags] <command> [flags] [arguments]")
+	fmt.Fprintln(output)
+	fmt.Fprintln(output, "Commands:")
+
+	writer := tabwriter.NewWriter(output, 0, 4, 2, ' ', 0)
+	names := make([]string, 0, len(groups))
+
+	for name := range groups {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, cmd := range groups[name] {
+			fmt.Fprintf(writer, "  %s %s %s\t%s\n", name, cmd.name, cmd.usage, cmd.description)
+		}
+	}
+
+	for _, cmd := range topLevel {
+		fmt.Fprintf(writer, "  %s\t%s\n", cmd.name, cmd.description)
+	}
+
+	_ = writer.Flush()
+
+	fmt.Fprintln(output)
+	fmt.Fprintln(output, "Global flags:")
+
+	flags.SetOutput(output)
+	flags.PrintDefaults()
+}
+
+func runVersion(ctx context.Context, app *app, args []string) error {
+	fmt.Fprintf(app.stdout, "tilaa %s\n", go_tilaa.Version)
+
+	return nil
+}
+
+func withInterrupt(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	signals := make(chan os.Signal, 1)
+
+	signal.Notify(signals, os.Interrupt)
+
+	go func() {
+		select {
+		case <-signals:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
+
+func firstArg(args []string) []string {
+	if len(args) == 0 {
+		return nil
+	}
+
+	return args[:1]
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+	"github.com/pascal-splotches/go-tilaa/tilaatest"
+)
+
+func TestFindCommand(t *testing.T) {
+	tests := []struct {
+		args     []string
+		name     string
+		rest     []string
+		expected bool
+	}{
+		{[]string{"vm", "list", "--output", "json"}, "vm list", []string{"--output", "json"}, true},
+		{[]string{"sshkey", "sync", "keys"}, "sshkey sync", []string{"keys"}, true},
+		{[]string{"presets"}, "presets", []string{}, true},
+		{[]string{"vm"}, "", []string{}, false},
+		{[]string{"vm", "destroy"}, "", []string{"destroy"}, false},
+		{[]string{"list"}, "", []string{}, false},
+	}
+
+	for _, test := range tests {
+		cmd, rest, ok := findCommand(test.args[0], test.args[1:])
+
+		if ok != test.expected || cmd.name != test.name || !reflect.DeepEqual(rest, test.rest) {
+			t.Errorf("%v: got %q %v %v", test.args, cmd.name, rest, ok)
+		}
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	tests := []struct {
+		args       []string
+		name       string
+		positional []string
+	}{
+		{[]string{"1", "--name", "web1", "2"}, "web1", []string{"1", "2"}},
+		{[]string{"--name=web1", "1"}, "web1", []string{"1"}},
+		{[]string{"1", "--", "--name", "web1"}, "", []string{"1", "--name", "web1"}},
+		{[]string{}, "", nil},
+	}
+
+	for _, test := range tests {
+		flags := newFlagSet("test")
+		name := flags.String("name", "", "")
+
+		positional, err := parseFlags(flags, test.args)
+
+		if err != nil {
+			t.Errorf("%v: %v", test.args, err)
+
+			continue
+		}
+
+		if *name != test.name || !reflect.DeepEqual(positional, test.positional) {
+			t.Errorf("%v: got name %q and arguments %v", test.args, *name, positional)
+		}
+	}
+}
+
+func TestParseId(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected int
+		err      string
+	}{
+		{[]string{"42"}, 42, ""},
+		{[]string{}, 0, "missing id"},
+		{[]string{"web1"}, 0, `invalid id "web1"`},
+		{[]string{"0"}, 0, `invalid id "0"`},
+	}
+
+	for _, test := range tests {
+		id, err := parseId(test.args, 0, "id")
+
+		var usage *usageError
+
+		if test.err != "" && (!errors.As(err, &usage) || err.Error() != test.err) {
+			t.Errorf("%v: expected usage error %q, got %v", test.args, test.err, err)
+		}
+
+		if test.err == "" && (err != nil || id != test.expected) {
+			t.Errorf("%v: expected %d, got %d and %v", test.args, test.expected, id, err)
+		}
+	}
+}
+
+// runCommand runs a command line below the global flags against the server.
+func runCommand(t *testing.T, server *tilaatest.Server, args ...string) (string, string, error) {
+	client, err := server.Client()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd, rest, ok := findCommand(args[0], args[1:])
+
+	if !ok {
+		t.Fatalf("unknown command %v", args)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	err = cmd.run(context.Background(), &app{client: client, stdout: &stdout, stderr: &stderr}, rest)
+
+	return stdout.String(), stderr.String(), err
+}
+
+func TestVmCommands(t *testing.T) {
+	server := tilaatest.NewServer(tilaatest.WithTransitionDelay(0))
+	defer server.Close()
+
+	_, stderr, err := runCommand(t, server, "vm", "create", "--name", "web1", "--template", "Ubuntu 20.04", "--site", "AMS1")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stderr != "Created virtual machine 1\n" {
+		t.Errorf("unexpected output %q", stderr)
+	}
+
+	stdout, _, err := runCommand(t, server, "vm", "list", "--output", "template={{.id}} {{.name}} {{.ram}} {{.storage.size}}")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stdout != "1 web1 1024 10\n" {
+		t.Errorf("unexpected listing %q", stdout)
+	}
+
+	if _, stderr, err = runCommand(t, server, "vm", "stop", "1", "--wait"); err != nil {
+		t.Fatal(err)
+	}
+
+	if stderr != "Sent stop to virtual machine 1\n" {
+		t.Errorf("unexpected output %q", stderr)
+	}
+
+	if machine, _ := server.VirtualMachine(1); machine.Status != go_tilaa.VirtualMachineStatusStopped {
+		t.Errorf("expected the machine to be stopped, got %s", machine.Status)
+	}
+}
+
+func TestVmUsageErrors(t *testing.T) {
+	server := tilaatest.NewServer()
+	defer server.Close()
+
+	tests := map[string][]string{
+		"--name, --template and --site are required": {"vm", "create", "--name", "web1"},
+		"--ssd and --hdd are mutually exclusive": {"vm", "create", "--name", "web1", "--template", "Ubuntu 20.04",
+			"--site", "AMS1", "--ssd", "10", "--hdd", "100"},
+		"missing virtual machine id":        {"vm", "view"},
+		`invalid virtual machine id "web1"`: {"vm", "start", "web1"},
+	}
+
+	for expected, args := range tests {
+		_, _, err := runCommand(t, server, args...)
+
+		var usage *usageError
+
+		if !errors.As(err, &usage) || !strings.Contains(err.Error(), expected) {
+			t.Errorf("%v: expected usage error %q, got %v", args, expected, err)
+		}
+	}
+
+	if _, ok := server.VirtualMachine(1); ok {
+		t.Error("expected no machine to be created")
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+)
+
+var metadataCommands = []command{
+	{name: "list", description: "List metadata", run: runMetadataList},
+	{name: "view", usage: "<id>", description: "Show metadata including its user data", run: runMetadataView},
+	{name: "create", usage: "--name --user-data <file>", description: "Create metadata", run: runMetadataCreate},
+	{name: "edit", usage: "<id> [--name] [--user-data <file>]", description: "Edit metadata", run: runMetadataEdit},
+	{name: "delete", usage: "<id>", description: "Delete metadata", run: runMetadataDelete},
+}
+
+func runMetadataList(ctx context.Context, app *app, args []string) error {
+	flags := newFlagSet("metadata list")
+	options := addOutputFlags(flags)
+
+	if _, err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	metadata, err := app.client.Metadata.ListWithContext(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, len(*metadata))
+
+	for i, entry := range *metadata {
+		rows[i] = []string{
+			strconv.Itoa(entry.Id),
+			entry.Name,
+			strconv.Itoa(len(entry.UserData)),
+			formatTime(&entry.Created),
+			formatTime(&entry.Modified),
+		}
+	}
+
+	return app.print(options, output{columns: metadataListColumns, rows: rows, records: emptySlice(*metadata)})
+}
+
+func runMetadataView(ctx context.Context, app *app, args []string) error {
+	flags := newFlagSet("metadata view")
+	options := addOutputFlags(flags)
+
+	positional, err := parseFlags(flags, args)
+
+	if err != nil {
+		return err
+	}
+
+	metadata, err := viewMetadata(ctx, app, positional)
+
+	if err != nil {
+		return err
+	}
+
+	result := output{columns: metadataListColumns[:2], records: metadata, single: true, trailer: metadata.UserData}
+	result.rows = [][]string{{strconv.Itoa(metadata.Id), metadata.Name}}
+
+	if options.format == outputCsv {
+		result.columns = metadataColumns
+		result.rows = [][]string{{strconv.Itoa(metadata.Id), metadata.Name, metadata.UserData, formatTime(&metadata.Created), formatTime(&metadata.Modified)}}
+	}
+
+	return app.print(options, result)
+}
+
+func runMetadataCreate(ctx context.Context, app *app, args []string) error {
+	flags := newFlagSet("metadata create")
+	name := flags.String("name", "", "name of the metadata")
+	userData := flags.String("user-data", "", "file containing the user data, - for standard input")
+
+	if _, err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if *name == "" || *userData == "" {
+		return newUsageError("--name and --user-data are required")
+	}
+
+	contents, err := readInput(*userData)
+
+	if err != nil {
+		return err
+	}
+
+	metadata := go_tilaa.NewMetadata(app.client)
+	metadata.Name = *name
+	metadata.UserData = contents
+
+	if _, err := app.client.Metadata.AddWithContext(ctx, metadata); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(app.stderr, "Created metadata %d\n", metadata.Id)
+
+	return nil
+}
+
+func runMetadataEdit(ctx context.Context, app *app, args []string) error {
+	flags := newFlagSet("metadata edit")
+	name := flags.String("name", "", "new name")
+	userData := flags.String("user-data", "", "file containing the new user data, - for standard input")
+
+	positional, err := parseFlags(flags, args)
+
+	if err != nil {
+		return err
+	}
+
+	metadata, err := viewMetadata(ctx, app, positional)
+
+	if err != nil {
+		return err
+	}
+
+	if *name == "" && *userData == "" {
+		return newUsageError("nothing to change")
+	}
+
+	if *name != "" {
+		metadata.Name = *name
+	}
+
+	if *userData != "" {
+		contents, err := readInput(*userData)
+
+		if err != nil {
+			return err
+		}
+
+		metadata.UserData = contents
+	}
+
+	if _, err := app.client.Metadata.EditWithContext(ctx, metadata); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(app.stderr, "Updated metadata %d\n", metadata.Id)
+
+	return nil
+}
+
+func runMetadataDelete(ctx context.Context, app *app, args []string) error {
+	flags := newFlagSet("metadata delete")
+	yes := flags.Bool("yes", false, "do not ask for confirmation")
+
+	positional, err := parseFlags(flags, args)
+
+	if err != nil {
+		return err
+	}
+
+	metadata, err := viewMetadata(ctx, app, positional)
+
+	if err != nil {
+		return err
+	}
+
+	if err := confirm(app, *yes, "Delete metadata %s?", metadata.Name); err != nil {
+		return err
+	}
+
+	if err := app.client.Metadata.DeleteWithContext(ctx, metadata); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(app.stderr, "Deleted metadata %d\n", metadata.Id)
+
+	return nil
+}
+
+func viewMetadata(ctx context.Context, app *app, args []string) (*go_tilaa.Metadata, error) {
+	id, err := parseId(args, 0, "metadata id")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return app.client.Metadata.ViewWithContext(ctx, id)
+}
+
+var metadataListColumns = []column{
+	{"id", "ID"},
+	{"name", "NAME"},
+	{"user_data.size", "SIZE"},
+	{"created", "CREATED"},
+	{"modified", "MODIFIED"},
+}
+
+var metadataColumns = []column{
+	{"id", "ID"},
+	{"name", "Name"},
+	{"user_data", "User data"},
+	{"created", "Created"},
+	{"modified", "Modified"},
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/template"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	outputTable    = "table"
+	outputJson     = "json"
+	outputYaml     = "yaml"
+	outputCsv      = "csv"
+	outputTemplate = "template="
+
+	maskedSecret = "********"
+)
+
+type outputOptions struct {
+	format      string
+	showSecrets bool
+}
+
+// column is a field of a listing. The name is used as CSV header and is identical to the JSON field name of the model,
+// the header is shown in table output.
+type column struct {
+	name   string
+	header string
+}
+
+// output is the result of a listing or view command, which can be rendered in every output format.
+type output struct {
+	columns []column
+	rows    [][]string
+
+	// records holds the models, a slice for listings or a pointer for views, used for JSON, YAML and templates.
+	records interface{}
+
+	// single renders table output as a list of details rather than a table.
+	single bool
+
+	// trailer is appended to table output of a single record, such as the user data of metadata.
+	trailer string
+}
+
+func addOutputFlags(flags *flag.FlagSet) *outputOptions {
+	options := &outputOptions{}
+
+	flags.StringVar(&options.format, "output", outputTable, "output format: table, json, yaml, csv or template=<go template>")
+	flags.StringVar(&options.format, "o", outputTable, "shorthand for --output")
+	flags.BoolVar(&options.showSecrets, "show-secrets", false, "show secrets such as initial passwords")
+
+	return options
+}
+
+func (app *app) print(options *outputOptions, result output) error {
+	switch {
+	case options.format == outputTable || options.format == "":
+		return printTableOutput(app.stdout, result)
+	case options.format == outputJson:
+		return printJson(app.stdout, result.records)
+	case options.format == outputYaml:
+		return printYaml(app.stdout, result.records)
+	case options.format == outputCsv:
+		return printCsv(app.stdout, result)
+	case strings.HasPrefix(options.format, outputTemplate):
+		return printTemplate(app.stdout, strings.TrimPrefix(options.format, outputTemplate), result.records)
+	}
+
+	return newUsageError("unknown output format %q", options.format)
+}
+
+func printTableOutput(writer io.Writer, result output) error {
+	headers := make([]string, len(result.columns))
+
+	for i, column := range result.columns {
+		headers[i] = column.header
+	}
+
+	if !result.single {
+		return printTable(writer, headers, result.rows)
+	}
+
+	fields := make([][2]string, 0, len(headers))
+
+	for _, row := range result.rows {
+		for i, value := range row {
+			fields = append(fields, [2]string{headers[i], value})
+		}
+	}
+
+	if err := printDetails(writer, fields); err != nil {
+		return err
+	}
+
+	if result.trailer != "" {
+		_, err := fmt.Fprintf(writer, "\n%s\n", result.trailer)
+
+		return err
+	}
+
+	return nil
+}
+
+func printJson(writer io.Writer, records interface{}) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(records)
+}
+
+// printYaml renders the records through their JSON representation, so the field names match the JSON output.
+func printYaml(writer io.Writer, records interface{}) error {
+	generic, err := toGeneric(records)
+
+	if err != nil {
+		return err
+	}
+
+	encoded, err := yaml.Marshal(generic)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = writer.Write(encoded)
+
+	return err
+}
+
+func printCsv(writer io.Writer, result output) error {
+	csvWriter := csv.NewWriter(writer)
+	header := make([]string, len(result.columns))
+
+	for i, column := range result.columns {
+		header[i] = column.name
+	}
+
+	if err := csvWriter.Write(header); err != nil {
+		return err
+	}
+
+	if err := csvWriter.WriteAll(result.rows); err != nil {
+		return err
+	}
+
+	return csvWriter.Error()
+}
+
+// printTemplate executes the template for every record. Fields are addressed by their JSON names, e.g. {{.name}}.
+func printTemplate(writer io.Writer, text string, records interface{}) error {
+	parsed, err := template.New("output").Option("missingkey=zero").Parse(text)
+
+	if err != nil {
+		return newUsageError("invalid template: %s", err)
+	}
+
+	generic, err := toGeneric(records)
+
+	if err != nil {
+		return err
+	}
+
+	items, ok := generic.([]interface{})
+
+	if !ok {
+		items = []interface{}{generic}
+	}
+
+	for _, item := range items {
+		if err := parsed.Execute(writer, item); err != nil {
+			return err
+		}
+
+		if !strings.HasSuffix(text, "\n") {
+			if _, err := io.WriteString(writer, "\n"); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// toGeneric converts the records into maps and slices keyed by their JSON field names.
+func toGeneric(records interface{}) (interface{}, error) {
+	encoded, err := json.Marshal(records)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var generic interface{}
+
+	decoder := json.NewDecoder(strings.NewReader(string(encoded)))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+
+	return generic, nil
+}
+
+// maskMachines hides the initial admin passwords unless secrets should be shown. The machines are copied, so the
+// originals keep their passwords.
+func maskMachines(options *outputOptions, machines []go_tilaa.VirtualMachine) []go_tilaa.VirtualMachine {
+	masked := make([]go_tilaa.VirtualMachine, len(machines))
+
+	copy(masked, machines)
+
+	if options.showSecrets {
+		return masked
+	}
+
+	for i := range masked {
+		if masked[i].Admin.InitialPassword != "" {
+			masked[i].Admin.InitialPassword = maskedSecret
+		}
+	}
+
+	return masked
+}
+
+// emptySlice makes sure an empty listing is rendered as [] rather than null.
+func emptySlice(records interface{}) interface{} {
+	value := reflect.ValueOf(records)
+
+	if value.Kind() == reflect.Slice && value.IsNil() {
+		return reflect.MakeSlice(value.Type(), 0, 0).Interface()
+	}
+
+	return records
+}
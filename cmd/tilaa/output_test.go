@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+)
+
+func testOutput() output {
+	machines := []go_tilaa.VirtualMachine{
+		{Id: 1, Name: "web1", Status: go_tilaa.VirtualMachineStatusRunning, Ram: 1024},
+		{Id: 2, Name: "db1", Status: go_tilaa.VirtualMachineStatusStopped, Ram: 2048},
+	}
+
+	return output{
+		columns: []column{{"id", "ID"}, {"name", "NAME"}, {"status", "STATUS"}},
+		rows:    [][]string{{"1", "web1", "running"}, {"2", "db1", "stopped"}},
+		records: machines,
+	}
+}
+
+func TestPrintFormats(t *testing.T) {
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"", "ID  NAME  STATUS\n1   web1  running\n2   db1   stopped\n"},
+		{"table", "ID  NAME  STATUS\n1   web1  running\n2   db1   stopped\n"},
+		{"csv", "id,name,status\n1,web1,running\n2,db1,stopped\n"},
+		{"template={{.name}} {{.status}}", "web1 running\ndb1 stopped\n"},
+		{"template={{.name}},", "web1,\ndb1,\n"},
+		{"template={{.storage.size}}\n", "0\n0\n"},
+	}
+
+	for _, test := range tests {
+		var stdout bytes.Buffer
+
+		app := &app{stdout: &stdout}
+
+		if err := app.print(&outputOptions{format: test.format}, testOutput()); err != nil {
+			t.Errorf("%s: %v", test.format, err)
+
+			continue
+		}
+
+		if stdout.String() != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.format, test.expected, stdout.String())
+		}
+	}
+}
+
+func TestPrintJsonAndYamlUseJsonFieldNames(t *testing.T) {
+	for format, expected := range map[string][]string{
+		"json": {`"id": 1`, `"name": "web1"`, `"storage": {`, `"is_managed": false`},
+		"yaml": {"  id: 1\n", "  name: web1\n", "  storage:\n", "  is_managed: false\n"},
+	} {
+		var stdout bytes.Buffer
+
+		app := &app{stdout: &stdout}
+
+		if err := app.print(&outputOptions{format: format}, testOutput()); err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+
+		for _, fragment := range expected {
+			if !bytes.Contains(stdout.Bytes(), []byte(fragment)) {
+				t.Errorf("%s output does not contain %q:\n%s", format, fragment, stdout.String())
+			}
+		}
+	}
+}
+
+func TestPrintSingleRecord(t *testing.T) {
+	var stdout bytes.Buffer
+
+	app := &app{stdout: &stdout}
+	result := output{
+		columns: []column{{"id", "ID"}, {"name", "Name"}},
+		rows:    [][]string{{"7", "init"}},
+		records: &go_tilaa.Metadata{Id: 7, Name: "init"},
+		single:  true,
+		trailer: "#cloud-config",
+	}
+
+	if err := app.print(&outputOptions{format: outputTable}, result); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "ID:    7\nName:  init\n\n#cloud-config\n"; stdout.String() != expected {
+		t.Errorf("expected %q, got %q", expected, stdout.String())
+	}
+
+	stdout.Reset()
+
+	if err := app.print(&outputOptions{format: "template={{.id}}"}, result); err != nil {
+		t.Fatal(err)
+	}
+
+	if stdout.String() != "7\n" {
+		t.Errorf("expected a single line, got %q", stdout.String())
+	}
+}
+
+func TestPrintUsageErrors(t *testing.T) {
+	var usage *usageError
+
+	for _, format := range []string{"xml", "template={{.name"} {
+		app := &app{stdout: &bytes.Buffer{}}
+
+		if err := app.print(&outputOptions{format: format}, testOutput()); !errors.As(err, &usage) {
+			t.Errorf("%s: expected a usage error, got %v", format, err)
+		}
+	}
+}
+
+func TestPrintEmptyListing(t *testing.T) {
+	var stdout bytes.Buffer
+
+	app := &app{stdout: &stdout}
+
+	var machines []go_tilaa.VirtualMachine
+
+	if err := app.print(&outputOptions{format: outputJson}, output{records: emptySlice(machines)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if stdout.String() != "[]\n" {
+		t.Errorf("expected an empty JSON array, got %q", stdout.String())
+	}
+}
+
+func TestMaskMachines(t *testing.T) {
+	machines := []go_tilaa.VirtualMachine{{Id: 1, Admin: go_tilaa.Admin{Account: "root", InitialPassword: "secret"}}, {Id: 2}}
+
+	masked := maskMachines(&outputOptions{}, machines)
+
+	if masked[0].Admin.InitialPassword != maskedSecret || masked[1].Admin.InitialPassword != "" {
+		t.Errorf("unexpected passwords %q and %q", masked[0].Admin.InitialPassword, masked[1].Admin.InitialPassword)
+	}
+
+	if machines[0].Admin.InitialPassword != "secret" {
+		t.Error("the original machine was masked")
+	}
+
+	if shown := maskMachines(&outputOptions{showSecrets: true}, machines); shown[0].Admin.InitialPassword != "secret" {
+		t.Error("--show-secrets did not show the password")
+	}
+}
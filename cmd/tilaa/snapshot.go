@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+)
+
+var snapshotCommands = []command{
+	{name: "list", description: "List snapshots", run: runSnapshotList},
+	{name: "view", usage: "<id>", description: "Show a snapshot", run: runSnapshotView},
+	{name: "create", usage: "<vm-id> --name [--online] [--overwrite] [--wait]", description: "Create a snapshot of a virtual machine", run: runSnapshotCreate},
+	{name: "rename", usage: "<id> <name>", description: "Rename a snapshot", run: runSnapshotRename},
+	{name: "delete", usage: "<id>", description: "Delete a snapshot", run: runSnapshotDelete},
+	{name: "restore", usage: "<id> <vm-id>", description: "Restore a snapshot onto a virtual machine", run: runSnapshotRestore},
+}
+
+func runSnapshotList(ctx context.Context, app *app, args []string) error {
+	flags := newFlagSet("snapshot list")
+	options := addOutputFlags(flags)
+
+	if _, err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	snapshots, err := app.client.Snapshot.ListWithContext(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, len(*snapshots))
+
+	for i := range *snapshots {
+		rows[i] = snapshotRow(&(*snapshots)[i])
+	}
+
+	return app.print(options, output{columns: snapshotColumns, rows: rows, records: emptySlice(*snapshots)})
+}
+
+func runSnapshotView(ctx context.Context, app *app, args []string) error {
+	flags := newFlagSet("snapshot view")
+	options := addOutputFlags(flags)
+
+	positional, err := parseFlags(flags, args)
+
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := viewSnapshot(ctx, app, positional)
+
+	if err != nil {
+		return err
+	}
+
+	return app.print(options, output{columns: snapshotColumns, rows: [][]string{snapshotRow(snapshot)}, records: snapshot, single: true})
+}
+
+func runSnapshotCreate(ctx context.Context, app *app, args []string) error {
+	flags := newFlagSet("snapshot create")
+	name := flags.String("name", "", "name of the snapshot")
+	online := flags.Bool("online", false, "snapshot the machine while it is running")
+	overwrite := flags.Bool("overwrite", false, "overwrite an existing snapshot with the same name")
+	wait := flags.Bool("wait", false, "wait until the snapshot is done and print it")
+	options := addOutputFlags(flags)
+
+	positional, err := parseFlags(flags, args)
+
+	if err != nil {
+		return err
+	}
+
+	if *name == "" {
+		return newUsageError("--name is required")
+	}
+
+	machine, err := viewMachine(ctx, app, positional)
+
+	if err != nil {
+		return err
+	}
+
+	started := time.Now()
+
+	if _, err := app.client.VirtualMachine.CreateSnapshotWithContext(ctx, machine, *name, *online, *overwrite); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(app.stderr, "Creating snapshot %s of virtual machine %d\n", *name, machine.Id)
+
+	if !*wait {
+		return nil
+	}
+
+	snapshot, err := go_tilaa.ResolveSnapshot(ctx, app.client, *name, started)
+
+	if err != nil {
+		return err
+	}
+
+	return app.print(options, output{columns: snapshotColumns, rows: [][]string{snapshotRow(snapshot)}, records: snapshot, single: true})
+}
+
+func runSnapshotRename(ctx context.Context, app *app, args []string) error {
+	flags := newFlagSet("snapshot rename")
+
+	positional, err := parseFlags(flags, args)
+
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 2 {
+		return newUsageError("expected a snapshot id and a name")
+	}
+
+	snapshot, err := viewSnapshot(ctx, app, positional)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := app.client.Snapshot.RenameWithContext(ctx, snapshot, positional[1]); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(app.stderr, "Renamed snapshot %d to %s\n", snapshot.Id, snapshot.Name)
+
+	return nil
+}
+
+func runSnapshotDelete(ctx context.Context, app *app, args []string) error {
+	flags := newFlagSet("snapshot delete")
+	yes := flags.Bool("yes", false, "do not ask for confirmation")
+
+	positional, err := parseFlags(flags, args)
+
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := viewSnapshot(ctx, app, positional)
+
+	if err != nil {
+		return err
+	}
+
+	if err := confirm(app, *yes, "Delete snapshot %s?", snapshot.Name); err != nil {
+		return err
+	}
+
+	if err := app.client.Snapshot.DeleteWithContext(ctx, snapshot); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(app.stderr, "Deleted snapshot %d\n", snapshot.Id)
+
+	return nil
+}
+
+func runSnapshotRestore(ctx context.Context, app *app, args []string) error {
+	flags := newFlagSet("snapshot restore")
+	yes := flags.Bool("yes", false, "do not ask for confirmation")
+
+	positional, err := parseFlags(flags, args)
+
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := viewSnapshot(ctx, app, positional)
+
+	if err != nil {
+		return err
+	}
+
+	machine, err := viewMachine(ctx, app, positional[1:])
+
+	if err != nil {
+		return err
+	}
+
+	if err := confirm(app, *yes, "Restoring %s onto %s overwrites all of its data, continue?", snapshot.Name, machine.Name); err != nil {
+		return err
+	}
+
+	if _, err := app.client.Snapshot.RestoreWithContext(ctx, machine, snapshot); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(app.stderr, "Restoring snapshot %d onto virtual machine %d\n", snapshot.Id, machine.Id)
+
+	return nil
+}
+
+func viewSnapshot(ctx context.Context, app *app, args []string) (*go_tilaa.Snapshot, error) {
+	id, err := parseId(args, 0, "snapshot id")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return app.client.Snapshot.ViewWithContext(ctx, id)
+}
+
+var snapshotColumns = []column{
+	{"id", "ID"},
+	{"name", "NAME"},
+	{"status", "STATUS"},
+	{"ram", "RAM"},
+	{"storage", "STORAGE"},
+	{"template.name", "TEMPLATE"},
+	{"created", "CREATED"},
+}
+
+func snapshotRow(snapshot *go_tilaa.Snapshot) []string {
+	return []string{
+		strconv.Itoa(snapshot.Id),
+		snapshot.Name,
+		string(snapshot.Status),
+		strconv.Itoa(snapshot.Ram),
+		strconv.Itoa(snapshot.Storage),
+		snapshot.Template.Name,
+		formatTime(&snapshot.Created),
+	}
+}
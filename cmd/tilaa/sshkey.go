@@ -0,0 +1,307 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+	"github.com/pascal-splotches/go-tilaa/reconcile"
+)
+
+var sshKeyCommands = []command{
+	{name: "list", description: "List SSH keys", run: runSshKeyList},
+	{name: "view", usage: "<id>", description: "Show an SSH key", run: runSshKeyView},
+	{name: "add", usage: "--label (--key | --file)", description: "Add an SSH key", run: runSshKeyAdd},
+	{name: "edit", usage: "<id> [--label] [--key | --file]", description: "Edit an SSH key", run: runSshKeyEdit},
+	{name: "delete", usage: "<id>", description: "Delete an SSH key", run: runSshKeyDelete},
+	{name: "sync", usage: "(--file | --dir) [--protect <label|fingerprint>] [--allow-empty] [--dry-run]", description: "Make the SSH keys match an authorized_keys file or directory", run: runSshKeySync},
+}
+
+func runSshKeyList(ctx context.Context, app *app, args []string) error {
+	flags := newFlagSet("sshkey list")
+	options := addOutputFlags(flags)
+
+	if _, err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	sshKeys, err := app.client.SshKey.ListWithContext(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, len(*sshKeys))
+
+	for i, sshKey := range *sshKeys {
+		rows[i] = []string{
+			strconv.Itoa(sshKey.Id),
+			sshKey.Label,
+			abbreviateKey(sshKey.Key),
+			formatTime(&sshKey.Modified),
+		}
+
+		if options.format == outputCsv {
+			rows[i][2] = sshKey.Key
+		}
+	}
+
+	return app.print(options, output{columns: sshKeyListColumns, rows: rows, records: emptySlice(*sshKeys)})
+}
+
+func runSshKeyView(ctx context.Context, app *app, args []string) error {
+	flags := newFlagSet("sshkey view")
+	options := addOutputFlags(flags)
+
+	positional, err := parseFlags(flags, args)
+
+	if err != nil {
+		return err
+	}
+
+	sshKey, err := viewSshKey(ctx, app, positional)
+
+	if err != nil {
+		return err
+	}
+
+	row := []string{
+		strconv.Itoa(sshKey.Id),
+		strconv.Itoa(sshKey.UserId),
+		sshKey.Label,
+		sshKey.Key,
+		formatTime(&sshKey.Created),
+		formatTime(&sshKey.Modified),
+	}
+
+	return app.print(options, output{columns: sshKeyColumns, rows: [][]string{row}, records: sshKey, single: true})
+}
+
+func runSshKeyAdd(ctx context.Context, app *app, args []string) error {
+	flags := newFlagSet("sshkey add")
+	label := flags.String("label", "", "label of the key")
+	key := flags.String("key", "", "public key")
+	file := flags.String("file", "", "file containing the public key, - for standard input")
+
+	if _, err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	publicKey, err := publicKeyArgument(*key, *file)
+
+	if err != nil {
+		return err
+	}
+
+	if *label == "" || publicKey == "" {
+		return newUsageError("--label and --key or --file are required")
+	}
+
+	sshKey := go_tilaa.NewSshKey(app.client)
+	sshKey.Label = *label
+	sshKey.Key = publicKey
+
+	if _, err := app.client.SshKey.AddWithContext(ctx, sshKey); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(app.stderr, "Added SSH key %s with id %d\n", sshKey.Label, sshKey.Id)
+
+	return nil
+}
+
+func runSshKeyEdit(ctx context.Context, app *app, args []string) error {
+	flags := newFlagSet("sshkey edit")
+	label := flags.String("label", "", "new label")
+	key := flags.String("key", "", "new public key")
+	file := flags.String("file", "", "file containing the new public key, - for standard input")
+
+	positional, err := parseFlags(flags, args)
+
+	if err != nil {
+		return err
+	}
+
+	sshKey, err := viewSshKey(ctx, app, positional)
+
+	if err != nil {
+		return err
+	}
+
+	publicKey, err := publicKeyArgument(*key, *file)
+
+	if err != nil {
+		return err
+	}
+
+	if *label == "" && publicKey == "" {
+		return newUsageError("nothing to change")
+	}
+
+	if *label != "" {
+		sshKey.Label = *label
+	}
+
+	if publicKey != "" {
+		sshKey.Key = publicKey
+	}
+
+	if _, err := app.client.SshKey.EditWithContext(ctx, sshKey); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(app.stderr, "Updated SSH key %d\n", sshKey.Id)
+
+	return nil
+}
+
+func runSshKeyDelete(ctx context.Context, app *app, args []string) error {
+	flags := newFlagSet("sshkey delete")
+	yes := flags.Bool("yes", false, "do not ask for confirmation")
+
+	positional, err := parseFlags(flags, args)
+
+	if err != nil {
+		return err
+	}
+
+	sshKey, err := viewSshKey(ctx, app, positional)
+
+	if err != nil {
+		return err
+	}
+
+	if err := confirm(app, *yes, "Delete SSH key %s?", sshKey.Label); err != nil {
+		return err
+	}
+
+	if err := app.client.SshKey.DeleteWithContext(ctx, sshKey); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(app.stderr, "Deleted SSH key %d\n", sshKey.Id)
+
+	return nil
+}
+
+func runSshKeySync(ctx context.Context, app *app, args []string) error {
+	flags := newFlagSet("sshkey sync")
+	file := flags.String("file", "", "authorized_keys file with the desired keys")
+	dir := flags.String("dir", "", "directory with a .pub file per desired key")
+	dryRun := flags.Bool("dry-run", false, "only print the changes")
+	yes := flags.Bool("yes", false, "do not ask for confirmation")
+	allowEmpty := flags.Bool("allow-empty", false, "delete every unprotected key when the file or directory has no keys")
+
+	var protect stringList
+	flags.Var(&protect, "protect", "label or SHA256 fingerprint of a key never to delete, may be repeated")
+
+	if _, err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if (*file == "") == (*dir == "") {
+		return newUsageError("either --file or --dir is required")
+	}
+
+	var manifest *reconcile.SshKeyManifest
+	var err error
+
+	if *file != "" {
+		manifest, err = reconcile.LoadAuthorizedKeys(*file)
+	} else {
+		manifest, err = reconcile.LoadSshKeyDir(*dir)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	manifest.Protect = protect
+	manifest.AllowEmpty = *allowEmpty
+
+	reconciler := reconcile.NewReconciler(app.client)
+
+	plan, err := reconciler.PlanSshKeys(ctx, manifest)
+
+	if errors.Is(err, reconcile.ErrEmptySshKeyManifest) {
+		return newUsageError("%s, use --allow-empty to delete every unprotected key", err)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(app.stdout, plan)
+
+	if *dryRun || plan.Empty() {
+		return nil
+	}
+
+	if err := confirm(app, *yes, "Apply %d changes?", len(plan.Steps)); err != nil {
+		return err
+	}
+
+	// The deletions were listed in the plan which was just confirmed
+	reconciler.AllowDestructive = true
+	reconciler.Progress = func(step reconcile.Step, status reconcile.StepStatus, err error) {
+		if status == reconcile.StepFailed {
+			fmt.Fprintf(app.stderr, "Failed to %s SSH key %s: %s\n", step.Action, step.Name, err)
+		}
+	}
+
+	return reconciler.Apply(ctx, plan)
+}
+
+func viewSshKey(ctx context.Context, app *app, args []string) (*go_tilaa.SshKey, error) {
+	id, err := parseId(args, 0, "SSH key id")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return app.client.SshKey.ViewWithContext(ctx, id)
+}
+
+func publicKeyArgument(key string, file string) (string, error) {
+	if key != "" && file != "" {
+		return "", newUsageError("--key and --file are mutually exclusive")
+	}
+
+	if file != "" {
+		contents, err := readInput(file)
+
+		return strings.TrimSpace(contents), err
+	}
+
+	return key, nil
+}
+
+var sshKeyListColumns = []column{
+	{"id", "ID"},
+	{"label", "LABEL"},
+	{"key", "KEY"},
+	{"modified", "MODIFIED"},
+}
+
+var sshKeyColumns = []column{
+	{"id", "ID"},
+	{"user_id", "User ID"},
+	{"label", "Label"},
+	{"key", "Key"},
+	{"created", "Created"},
+	{"modified", "Modified"},
+}
+
+// abbreviateKey shortens the key material of a public key for table output.
+func abbreviateKey(key string) string {
+	fields := strings.Fields(key)
+
+	if len(fields) < 2 || len(fields[1]) <= 20 {
+		return key
+	}
+
+	return fields[0] + " " + fields[1][:8] + "..." + fields[1][len(fields[1])-8:]
+}
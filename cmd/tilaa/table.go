@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// printTable writes the rows as aligned columns below the given headers.
+func printTable(output io.Writer, headers []string, rows [][]string) error {
+	writer := tabwriter.NewWriter(output, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(writer, strings.Join(headers, "\t"))
+
+	for _, row := range rows {
+		fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+
+	return writer.Flush()
+}
+
+// printDetails writes the fields as aligned "key: value" lines.
+func printDetails(output io.Writer, fields [][2]string) error {
+	writer := tabwriter.NewWriter(output, 0, 4, 2, ' ', 0)
+
+	for _, field := range fields {
+		fmt.Fprintf(writer, "%s:\t%s\n", field[0], field[1])
+	}
+
+	return writer.Flush()
+}
+
+func formatTime(value *time.Time) string {
+	if value == nil || value.IsZero() {
+		return "-"
+	}
+
+	return value.Format(time.RFC3339)
+}
+
+func formatInts(values []int) string {
+	if len(values) == 0 {
+		return "-"
+	}
+
+	parts := make([]string, len(values))
+
+	for i, value := range values {
+		parts[i] = fmt.Sprint(value)
+	}
+
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,514 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+)
+
+var vmCommands = []command{
+	{name: "list", description: "List virtual machines", run: runVmList},
+	{name: "view", usage: "<id>", description: "Show a virtual machine", run: runVmView},
+	{name: "create", usage: "--name --template --site --ram --ssd|--hdd", description: "Create a virtual machine", run: runVmCreate},
+	{name: "edit", usage: "<id> [--name] [--ram] [--storage] [--cpu] [--cpu-cap]", description: "Edit a virtual machine", run: runVmEdit},
+	{name: "start", usage: "<id>", description: "Start a virtual machine", run: vmTask(go_tilaa.TaskStart, go_tilaa.VirtualMachineStatusRunning)},
+	{name: "stop", usage: "<id>", description: "Stop a virtual machine", run: vmTask(go_tilaa.TaskStop, go_tilaa.VirtualMachineStatusStopped)},
+	{name: "restart", usage: "<id>", description: "Restart a virtual machine", run: vmTask(go_tilaa.TaskRestart, go_tilaa.VirtualMachineStatusRunning)},
+	{name: "poweroff", usage: "<id>", description: "Power off a virtual machine", run: vmTask(go_tilaa.TaskPowerOff, go_tilaa.VirtualMachineStatusStopped)},
+	{name: "rescue", usage: "<id>", description: "Boot a virtual machine into rescue mode", run: vmTask(go_tilaa.TaskRescue, go_tilaa.VirtualMachineStatusRunning_Rescue)},
+	{name: "reinstall", usage: "<id> [--template]", description: "Reinstall a virtual machine, destroying its data", run: runVmReinstall},
+	{name: "cancel", usage: "<id> [--date YYYY-MM-DD]", description: "Cancel a virtual machine", run: runVmCancel},
+	{name: "uncancel", usage: "<id>", description: "Undo the cancellation of a virtual machine", run: runVmUncancel},
+}
+
+func runVmList(ctx context.Context, app *app, args []string) error {
+	flags := newFlagSet("vm list")
+	options := addOutputFlags(flags)
+
+	if _, err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	machines, err := app.client.VirtualMachine.ListWithContext(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	masked := maskMachines(options, *machines)
+	rows := make([][]string, len(masked))
+
+	for i := range masked {
+		rows[i] = machineListRow(&masked[i])
+	}
+
+	return app.print(options, output{columns: machineListColumns, rows: rows, records: emptySlice(masked)})
+}
+
+func runVmView(ctx context.Context, app *app, args []string) error {
+	flags := newFlagSet("vm view")
+	options := addOutputFlags(flags)
+
+	positional, err := parseFlags(flags, args)
+
+	if err != nil {
+		return err
+	}
+
+	machine, err := viewMachine(ctx, app, positional)
+
+	if err != nil {
+		return err
+	}
+
+	return printMachine(app, options, machine)
+}
+
+func runVmCreate(ctx context.Context, app *app, args []string) error {
+	var sshKeys intList
+
+	flags := newFlagSet("vm create")
+	name := flags.String("name", "", "name of the machine")
+	dnsName := flags.String("dns-name", "", "DNS name of the machine")
+	template := flags.String("template", "", "name of the template to install")
+	site := flags.String("site", "", "name of the site to create the machine in")
+	ram := flags.Int("ram", 0, "RAM in MB, defaults to the template minimum")
+	ssd := flags.Int("ssd", 0, "SSD storage in GB")
+	hdd := flags.Int("hdd", 0, "HDD storage in GB")
+	cpu := flags.Int("cpu", go_tilaa.MinCpuCores, "number of CPU cores")
+	cpuCap := flags.Int("cpu-cap", 0, "CPU cap in percent of a single core, defaults to the full capacity of all cores")
+	metadata := flags.Int("metadata", 0, "ID of the metadata to provision the machine with")
+	snapshot := flags.Int("snapshot", 0, "ID of the snapshot to create the machine from")
+	wait := flags.Bool("wait", false, "wait until the machine has been created and print it")
+	flags.Var(&sshKeys, "ssh-key", "ID of an SSH key to install, may be repeated")
+	options := addOutputFlags(flags)
+
+	if _, err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if *name == "" || *template == "" || *site == "" {
+		return newUsageError("--name, --template and --site are required")
+	}
+
+	spec := go_tilaa.NewVirtualMachineSpec(app.client).
+		WithName(*name).
+		WithDnsName(*dnsName).
+		WithTemplateName(*template).
+		WithSite(*site).
+		WithRam(*ram).
+		WithCpu(*cpu).
+		WithSshKeys(sshKeys...).
+		WithMetadata(*metadata)
+
+	switch {
+	case *ssd != 0 && *hdd != 0:
+		return newUsageError("--ssd and --hdd are mutually exclusive")
+	case *hdd != 0:
+		spec.WithHdd(*hdd)
+	default:
+		spec.WithSsd(*ssd)
+	}
+
+	if *cpuCap != 0 {
+		spec.WithCpuCap(*cpuCap)
+	}
+
+	var machine *go_tilaa.VirtualMachine
+	var err error
+
+	if *snapshot != 0 {
+		source, viewErr := app.client.Snapshot.ViewWithContext(ctx, *snapshot)
+
+		if viewErr != nil {
+			return viewErr
+		}
+
+		machine, err = spec.CreateFromSnapshot(ctx, source)
+	} else {
+		machine, err = spec.Create(ctx)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(app.stderr, "Created virtual machine %d\n", machine.Id)
+
+	if *wait {
+		if err := go_tilaa.WaitUntilCreated(ctx, machine); err != nil {
+			return err
+		}
+
+		return printMachine(app, options, machine)
+	}
+
+	return nil
+}
+
+func runVmEdit(ctx context.Context, app *app, args []string) error {
+	flags := newFlagSet("vm edit")
+	name := flags.String("name", "", "new name")
+	ram := flags.Int("ram", 0, "new RAM size in MB")
+	storage := flags.Int("storage", 0, "new storage size in GB, shrinking requires a reinstall")
+	cpu := flags.Int("cpu", 0, "new number of CPU cores")
+	cpuCap := flags.Int("cpu-cap", -1, "new CPU cap in percent of a single core")
+	yes := flags.Bool("yes", false, "do not ask for confirmation when shrinking storage")
+
+	positional, err := parseFlags(flags, args)
+
+	if err != nil {
+		return err
+	}
+
+	machine, err := viewMachine(ctx, app, positional)
+
+	if err != nil {
+		return err
+	}
+
+	changed := false
+
+	setters := []struct {
+		set   bool
+		apply func() error
+	}{
+		{*name != "", func() error { return machine.SetName(*name) }},
+		{*ram != 0, func() error { return machine.SetRam(*ram) }},
+		{*storage != 0, func() error { return machine.SetStorage(*storage) }},
+		{*cpu != 0, func() error { return machine.SetCpuCores(*cpu) }},
+		{*cpuCap >= 0, func() error { return machine.SetCpuCap(*cpuCap) }},
+	}
+
+	if *storage != 0 && *storage < machine.Storage.Size {
+		if err := confirm(app, *yes, "Shrinking the storage of %s reinstalls it and destroys its data, continue?", machine.Name); err != nil {
+			return err
+		}
+	}
+
+	for _, setter := range setters {
+		if !setter.set {
+			continue
+		}
+
+		if err := setter.apply(); err != nil {
+			return err
+		}
+
+		changed = true
+	}
+
+	if !changed {
+		return newUsageError("nothing to change")
+	}
+
+	if _, err := app.client.VirtualMachine.EditWithContext(ctx, machine); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(app.stderr, "Updated virtual machine %d\n", machine.Id)
+
+	return nil
+}
+
+// vmTask creates a command running the given task, optionally waiting for the machine to reach the given status.
+func vmTask(task string, status go_tilaa.VirtualMachineStatus) func(ctx context.Context, app *app, args []string) error {
+	return func(ctx context.Context, app *app, args []string) error {
+		flags := newFlagSet("vm " + task)
+		wait := flags.Bool("wait", false, "wait until the machine is "+string(status))
+
+		positional, err := parseFlags(flags, args)
+
+		if err != nil {
+			return err
+		}
+
+		machine, err := viewMachine(ctx, app, positional)
+
+		if err != nil {
+			return err
+		}
+
+		if _, err := app.client.VirtualMachine.RunTaskWithContext(ctx, task, machine); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(app.stderr, "Sent %s to virtual machine %d\n", task, machine.Id)
+
+		if *wait {
+			return go_tilaa.WaitForStatus(ctx, machine, status)
+		}
+
+		return nil
+	}
+}
+
+func runVmReinstall(ctx context.Context, app *app, args []string) error {
+	flags := newFlagSet("vm reinstall")
+	template := flags.String("template", "", "name of the template to install, defaults to the current template")
+	yes := flags.Bool("yes", false, "do not ask for confirmation")
+
+	positional, err := parseFlags(flags, args)
+
+	if err != nil {
+		return err
+	}
+
+	machine, err := viewMachine(ctx, app, positional)
+
+	if err != nil {
+		return err
+	}
+
+	if *template != "" {
+		templates, err := app.client.Template.ListWithContext(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		found := false
+
+		for _, candidate := range *templates {
+			if strings.EqualFold(candidate.Name, *template) {
+				machine.Template = candidate
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("no template named %q", *template)
+		}
+	}
+
+	if err := confirm(app, *yes, "Reinstalling %s with %s destroys all of its data, continue?", machine.Name, machine.Template.Name); err != nil {
+		return err
+	}
+
+	if _, err := app.client.VirtualMachine.ReinstallWithContext(ctx, machine); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(app.stderr, "Reinstalling virtual machine %d\n", machine.Id)
+
+	return nil
+}
+
+func runVmCancel(ctx context.Context, app *app, args []string) error {
+	flags := newFlagSet("vm cancel")
+	date := flags.String("date", "", "cancellation date (YYYY-MM-DD), defaults to the earliest possible date")
+	list := flags.Bool("list-dates", false, "only list the possible cancellation dates")
+	yes := flags.Bool("yes", false, "do not ask for confirmation")
+
+	positional, err := parseFlags(flags, args)
+
+	if err != nil {
+		return err
+	}
+
+	machine, err := viewMachine(ctx, app, positional)
+
+	if err != nil {
+		return err
+	}
+
+	dates, err := app.client.VirtualMachine.GetCancelDatesWithContext(ctx, machine)
+
+	if err != nil {
+		return err
+	}
+
+	if *list {
+		for _, cancelDate := range *dates {
+			fmt.Fprintln(app.stdout, cancelDate.Format("2006-01-02"))
+		}
+
+		return nil
+	}
+
+	cancelDate, err := selectCancelDate(*dates, *date)
+
+	if err != nil {
+		return err
+	}
+
+	if err := confirm(app, *yes, "Cancel %s on %s?", machine.Name, cancelDate.Format("2006-01-02")); err != nil {
+		return err
+	}
+
+	if _, err := app.client.VirtualMachine.CancelWithContext(ctx, machine, cancelDate); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(app.stderr, "Virtual machine %d will be cancelled on %s\n", machine.Id, cancelDate.Format("2006-01-02"))
+
+	return nil
+}
+
+func runVmUncancel(ctx context.Context, app *app, args []string) error {
+	flags := newFlagSet("vm uncancel")
+
+	positional, err := parseFlags(flags, args)
+
+	if err != nil {
+		return err
+	}
+
+	machine, err := viewMachine(ctx, app, positional)
+
+	if err != nil {
+		return err
+	}
+
+	if err := app.client.VirtualMachine.UndoCancellationWithContext(ctx, machine); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(app.stderr, "Cancellation of virtual machine %d undone\n", machine.Id)
+
+	return nil
+}
+
+func viewMachine(ctx context.Context, app *app, args []string) (*go_tilaa.VirtualMachine, error) {
+	id, err := parseId(args, 0, "virtual machine id")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return app.client.VirtualMachine.ViewWithContext(ctx, id)
+}
+
+func selectCancelDate(dates []time.Time, requested string) (*time.Time, error) {
+	if len(dates) == 0 {
+		return nil, fmt.Errorf("no cancellation dates available")
+	}
+
+	if requested == "" {
+		earliest := dates[0]
+
+		for _, date := range dates {
+			if date.Before(earliest) {
+				earliest = date
+			}
+		}
+
+		return &earliest, nil
+	}
+
+	for _, date := range dates {
+		if date.Format("2006-01-02") == requested {
+			return &date, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s is not a possible cancellation date, see --list-dates", requested)
+}
+
+var machineListColumns = []column{
+	{"id", "ID"},
+	{"name", "NAME"},
+	{"status", "STATUS"},
+	{"ram", "RAM"},
+	{"storage.size", "STORAGE"},
+	{"storage.type", "TYPE"},
+	{"cpu.count", "CPU"},
+	{"template.name", "TEMPLATE"},
+	{"site.name", "SITE"},
+	{"network.address", "ADDRESS"},
+}
+
+var machineColumns = []column{
+	{"id", "ID"},
+	{"name", "Name"},
+	{"status", "Status"},
+	{"ram", "RAM"},
+	{"storage.size", "Storage"},
+	{"storage.type", "Storage type"},
+	{"cpu.count", "CPU cores"},
+	{"cpu.cap", "CPU cap"},
+	{"template.name", "Template"},
+	{"site.name", "Site"},
+	{"network.address", "Addresses"},
+	{"network.dns_name", "DNS name"},
+	{"ssh_keys", "SSH keys"},
+	{"metadata", "Metadata"},
+	{"admin.account", "Admin account"},
+	{"admin.initial_password", "Admin password"},
+	{"is_managed", "Managed"},
+	{"locked", "Locked"},
+	{"created", "Created"},
+	{"cancelled", "Cancelled"},
+}
+
+func machineListRow(machine *go_tilaa.VirtualMachine) []string {
+	return []string{
+		strconv.Itoa(machine.Id),
+		machine.Name,
+		string(machine.Status),
+		strconv.Itoa(machine.Ram),
+		strconv.Itoa(machine.Storage.Size),
+		string(machine.Storage.Type),
+		strconv.Itoa(machine.Cpu.Cores),
+		machine.Template.Name,
+		machine.Site.Name,
+		primaryAddress(machine),
+	}
+}
+
+func printMachine(app *app, options *outputOptions, machine *go_tilaa.VirtualMachine) error {
+	masked := maskMachines(options, []go_tilaa.VirtualMachine{*machine})[0]
+
+	addresses := make([]string, 0, len(masked.Network))
+
+	for _, network := range masked.Network {
+		if network.Address != nil {
+			addresses = append(addresses, network.Address.String())
+		}
+	}
+
+	row := []string{
+		strconv.Itoa(masked.Id),
+		masked.Name,
+		string(masked.Status),
+		strconv.Itoa(masked.Ram),
+		strconv.Itoa(masked.Storage.Size),
+		string(masked.Storage.Type),
+		strconv.Itoa(masked.Cpu.Cores),
+		strconv.Itoa(masked.Cpu.Cap),
+		masked.Template.Name,
+		masked.Site.Name,
+		strings.Join(addresses, ","),
+		masked.DnsName(),
+		formatInts(masked.SshKeys.Ints()),
+		strconv.Itoa(int(masked.Metadata)),
+		masked.Admin.Account,
+		masked.Admin.InitialPassword,
+		strconv.FormatBool(masked.Managed),
+		strconv.FormatBool(masked.Locked),
+		formatTime(masked.Created),
+		formatTime(masked.Cancelled),
+	}
+
+	return app.print(options, output{columns: machineColumns, rows: [][]string{row}, records: &masked, single: true})
+}
+
+func primaryAddress(machine *go_tilaa.VirtualMachine) string {
+	for _, network := range machine.Network {
+		if network.Family == go_tilaa.NetworkFamilyIpv4 && network.Address != nil {
+			return network.Address.String()
+		}
+	}
+
+	if len(machine.Network) > 0 && machine.Network[0].Address != nil {
+		return machine.Network[0].Address.String()
+	}
+
+	return "-"
+}
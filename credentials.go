@@ -0,0 +1,568 @@
+package go_tilaa
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redactedPassword replaces passwords whenever BasicAuth is formatted.
+const redactedPassword = "********"
+
+var _ fmt.Stringer = BasicAuth{}
+var _ fmt.GoStringer = BasicAuth{}
+var _ fmt.Formatter = BasicAuth{}
+
+func (credentials BasicAuth) String() string {
+	return fmt.Sprintf("{UserName:%s Password:%s}", credentials.UserName, credentials.redactedPassword())
+}
+
+func (credentials BasicAuth) GoString() string {
+	return fmt.Sprintf("go_tilaa.BasicAuth{UserName:%q, Password:%q}", credentials.UserName, credentials.redactedPassword())
+}
+
+// Format makes sure the password is redacted regardless of the verb used, as fmt would otherwise print the raw struct
+// fields for verbs such as %d or %x. See formatRedacted for how verbs, flags and width are applied.
+func (credentials BasicAuth) Format(state fmt.State, verb rune) {
+	formatRedacted(state, verb, credentials.String(), credentials.GoString())
+}
+
+var _ fmt.Stringer = &CachingCredentialsProvider{}
+var _ fmt.GoStringer = &CachingCredentialsProvider{}
+var _ fmt.Formatter = &CachingCredentialsProvider{}
+
+// String omits the cached credentials, which fmt would otherwise print unredacted as they are an unexported field.
+func (provider *CachingCredentialsProvider) String() string {
+	return fmt.Sprintf("{Provider:%v TTL:%s}", provider.Provider, provider.TTL)
+}
+
+func (provider *CachingCredentialsProvider) GoString() string {
+	return fmt.Sprintf("&go_tilaa.CachingCredentialsProvider{Provider:%#v, TTL:%#v}", provider.Provider, provider.TTL)
+}
+
+func (provider *CachingCredentialsProvider) Format(state fmt.State, verb rune) {
+	formatRedacted(state, verb, provider.String(), provider.GoString())
+}
+
+// formatRedacted writes the redacted representation of a value for any verb. %#v writes the Go syntax representation,
+// the string verbs %v, %s, %q, %x and %X are applied to the redacted string together with their flags, width and
+// precision, and any other verb, such as %d, writes the redacted string as is.
+func formatRedacted(state fmt.State, verb rune, redacted string, goSyntax string) {
+	switch verb {
+	case 'v':
+		if state.Flag('#') {
+			_, _ = fmt.Fprint(state, goSyntax)
+
+			return
+		}
+
+		_, _ = fmt.Fprintf(state, formatDirective(state, 's'), redacted)
+	case 's', 'q', 'x', 'X':
+		_, _ = fmt.Fprintf(state, formatDirective(state, verb), redacted)
+	default:
+		_, _ = fmt.Fprint(state, redacted)
+	}
+}
+
+// formatDirective rebuilds the directive, such as "%-10q", which is being formatted.
+func formatDirective(state fmt.State, verb rune) string {
+	directive := "%"
+
+	for _, flag := range "+-# 0" {
+		if state.Flag(int(flag)) {
+			directive += string(flag)
+		}
+	}
+
+	if width, ok := state.Width(); ok {
+		directive += strconv.Itoa(width)
+	}
+
+	if precision, ok := state.Precision(); ok {
+		directive += "." + strconv.Itoa(precision)
+	}
+
+	return directive + string(verb)
+}
+
+func (credentials BasicAuth) redactedPassword() string {
+	if credentials.Password == "" {
+		return ""
+	}
+
+	return redactedPassword
+}
+
+const (
+	EnvUserName   = "TILAA_USERNAME"
+	EnvPassword   = "TILAA_PASSWORD"
+	EnvConfigFile = "TILAA_CONFIG_FILE"
+	EnvProfile    = "TILAA_PROFILE"
+	EnvNetrc      = "NETRC"
+
+	DefaultProfile = "default"
+
+	// DefaultCredentialsTtl is how long DefaultClient caches the credentials found by DefaultCredentialsProvider.
+	DefaultCredentialsTtl = 5 * time.Minute
+)
+
+// ErrNoCredentials is returned by a CredentialsProvider which has no credentials to offer, allowing a
+// ChainCredentialsProvider to move on to the next provider.
+var ErrNoCredentials = errors.New("no credentials available")
+
+// CredentialsProvider resolves the credentials used to authenticate a request. Retrieve is called for every request,
+// so providers should be cheap or cache their results, see CachingCredentialsProvider.
+type CredentialsProvider interface {
+	Retrieve(ctx context.Context) (BasicAuth, error)
+}
+
+type StaticCredentialsProvider struct {
+	Credentials BasicAuth
+}
+
+type EnvCredentialsProvider struct {
+	UserNameVariable string
+	PasswordVariable string
+}
+
+// ConfigFileCredentialsProvider reads credentials from an INI style file with one section per profile:
+//
+//	[default]
+//	username = api@example.com
+//	password = secret
+type ConfigFileCredentialsProvider struct {
+	Path    string
+	Profile string
+}
+
+type NetrcCredentialsProvider struct {
+	Path    string
+	Machine string
+}
+
+// CommandCredentialsProvider runs an external credential helper which prints "username=..." and "password=..." lines
+// on its standard output. What the helper prints on its standard error is included in the error when it fails.
+type CommandCredentialsProvider struct {
+	Command string
+	Args    []string
+}
+
+type ChainCredentialsProvider struct {
+	Providers []CredentialsProvider
+}
+
+// CachingCredentialsProvider caches the credentials of another provider for the given TTL.
+type CachingCredentialsProvider struct {
+	Provider CredentialsProvider
+	TTL      time.Duration
+
+	mutex       sync.Mutex
+	credentials BasicAuth
+	expires     time.Time
+}
+
+var _ CredentialsProvider = &StaticCredentialsProvider{}
+var _ CredentialsProvider = &EnvCredentialsProvider{}
+var _ CredentialsProvider = &ConfigFileCredentialsProvider{}
+var _ CredentialsProvider = &NetrcCredentialsProvider{}
+var _ CredentialsProvider = &CommandCredentialsProvider{}
+var _ CredentialsProvider = &ChainCredentialsProvider{}
+var _ CredentialsProvider = &CachingCredentialsProvider{}
+
+func NewStaticCredentialsProvider(username string, password string) *StaticCredentialsProvider {
+	return &StaticCredentialsProvider{Credentials: BasicAuth{UserName: username, Password: password}}
+}
+
+func NewEnvCredentialsProvider() *EnvCredentialsProvider {
+	return &EnvCredentialsProvider{UserNameVariable: EnvUserName, PasswordVariable: EnvPassword}
+}
+
+// NewConfigFileCredentialsProvider reads the given profile from the given file. An empty path defaults to
+// $TILAA_CONFIG_FILE or ~/.tilaa/credentials, an empty profile to $TILAA_PROFILE or "default".
+func NewConfigFileCredentialsProvider(path string, profile string) *ConfigFileCredentialsProvider {
+	return &ConfigFileCredentialsProvider{Path: path, Profile: profile}
+}
+
+// NewNetrcCredentialsProvider reads the entry of the Tilaa API host from $NETRC or ~/.netrc.
+func NewNetrcCredentialsProvider() *NetrcCredentialsProvider {
+	host := BaseUrl
+
+	if parsedUrl, err := url.Parse(BaseUrl); err == nil {
+		host = parsedUrl.Hostname()
+	}
+
+	return &NetrcCredentialsProvider{Machine: host}
+}
+
+func NewCommandCredentialsProvider(command string, args ...string) *CommandCredentialsProvider {
+	return &CommandCredentialsProvider{Command: command, Args: args}
+}
+
+func NewChainCredentialsProvider(providers ...CredentialsProvider) *ChainCredentialsProvider {
+	return &ChainCredentialsProvider{Providers: providers}
+}
+
+func NewCachingCredentialsProvider(provider CredentialsProvider, ttl time.Duration) *CachingCredentialsProvider {
+	return &CachingCredentialsProvider{Provider: provider, TTL: ttl}
+}
+
+// DefaultCredentialsProvider looks for credentials in the environment, the default config file profile and ~/.netrc,
+// in that order.
+func DefaultCredentialsProvider() *ChainCredentialsProvider {
+	return NewChainCredentialsProvider(
+		NewEnvCredentialsProvider(),
+		NewConfigFileCredentialsProvider("", ""),
+		NewNetrcCredentialsProvider(),
+	)
+}
+
+// WithCredentialsProvider resolves the credentials of every request through the given provider.
+func WithCredentialsProvider(provider CredentialsProvider) Option {
+	return func(client *Client) error {
+		client.CredentialsProvider = provider
+
+		return nil
+	}
+}
+
+func (provider *StaticCredentialsProvider) Retrieve(ctx context.Context) (BasicAuth, error) {
+	return provider.Credentials, nil
+}
+
+func (provider *EnvCredentialsProvider) Retrieve(ctx context.Context) (BasicAuth, error) {
+	credentials := BasicAuth{
+		UserName: os.Getenv(provider.UserNameVariable),
+		Password: os.Getenv(provider.PasswordVariable),
+	}
+
+	if credentials.UserName == "" || credentials.Password == "" {
+		return BasicAuth{}, NewCredentialsProviderError("env", ErrNoCredentials)
+	}
+
+	return credentials, nil
+}
+
+func (provider *ConfigFileCredentialsProvider) Retrieve(ctx context.Context) (BasicAuth, error) {
+	path, err := provider.path()
+
+	if err != nil {
+		return BasicAuth{}, NewCredentialsProviderError("config file", err)
+	}
+
+	profiles, err := ReadConfigFile(path)
+
+	if os.IsNotExist(err) {
+		return BasicAuth{}, NewCredentialsProviderError("config file", ErrNoCredentials)
+	}
+
+	if err != nil {
+		return BasicAuth{}, NewCredentialsProviderError("config file", err)
+	}
+
+	profile := provider.profile()
+	values, ok := profiles[profile]
+
+	if !ok || values["username"] == "" || values["password"] == "" {
+		return BasicAuth{}, NewCredentialsProviderError("config file", fmt.Errorf("profile %q: %w", profile, ErrNoCredentials))
+	}
+
+	return BasicAuth{UserName: values["username"], Password: values["password"]}, nil
+}
+
+func (provider *ConfigFileCredentialsProvider) path() (string, error) {
+	if provider.Path != "" {
+		return provider.Path, nil
+	}
+
+	if path := os.Getenv(EnvConfigFile); path != "" {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".tilaa", "credentials"), nil
+}
+
+func (provider *ConfigFileCredentialsProvider) profile() string {
+	if provider.Profile != "" {
+		return provider.Profile
+	}
+
+	if profile := os.Getenv(EnvProfile); profile != "" {
+		return profile
+	}
+
+	return DefaultProfile
+}
+
+// ReadConfigFile parses an INI style config file into a map of profiles to their key/value pairs. Lines starting with
+// '#' or ';' are ignored.
+func ReadConfigFile(path string) (map[string]map[string]string, error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	profiles := map[string]map[string]string{}
+	profile := DefaultProfile
+	scanner := bufio.NewScanner(file)
+
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			profile = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line[1:len(line)-1]), "profile "))
+
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected key = value", path, lineNumber)
+		}
+
+		if profiles[profile] == nil {
+			profiles[profile] = map[string]string{}
+		}
+
+		profiles[profile][strings.ToLower(strings.TrimSpace(parts[0]))] = strings.TrimSpace(parts[1])
+	}
+
+	return profiles, scanner.Err()
+}
+
+func (provider *NetrcCredentialsProvider) Retrieve(ctx context.Context) (BasicAuth, error) {
+	path := provider.Path
+
+	if path == "" {
+		path = os.Getenv(EnvNetrc)
+	}
+
+	if path == "" {
+		home, err := os.UserHomeDir()
+
+		if err != nil {
+			return BasicAuth{}, NewCredentialsProviderError("netrc", err)
+		}
+
+		path = filepath.Join(home, ".netrc")
+	}
+
+	contents, err := ioutil.ReadFile(path)
+
+	if os.IsNotExist(err) {
+		return BasicAuth{}, NewCredentialsProviderError("netrc", ErrNoCredentials)
+	}
+
+	if err != nil {
+		return BasicAuth{}, NewCredentialsProviderError("netrc", err)
+	}
+
+	credentials, found := parseNetrc(string(contents), provider.Machine)
+
+	if !found || credentials.UserName == "" || credentials.Password == "" {
+		return BasicAuth{}, NewCredentialsProviderError("netrc", ErrNoCredentials)
+	}
+
+	return credentials, nil
+}
+
+// parseNetrc returns the login and password of the given machine, falling back to the default entry.
+func parseNetrc(contents string, machine string) (BasicAuth, bool) {
+	var (
+		machineCredentials BasicAuth
+		defaultCredentials BasicAuth
+		machineFound       bool
+		defaultFound       bool
+		current            *BasicAuth
+	)
+
+	lines := strings.Split(contents, "\n")
+
+	for i := 0; i < len(lines); i++ {
+		tokens := strings.Fields(lines[i])
+
+		for j := 0; j < len(tokens); j++ {
+			token := tokens[j]
+
+			if strings.HasPrefix(token, "#") {
+				break
+			}
+
+			switch token {
+			case "machine":
+				current = nil
+
+				if j+1 < len(tokens) {
+					j++
+
+					if tokens[j] == machine && !machineFound {
+						machineFound = true
+						current = &machineCredentials
+					}
+				}
+			case "default":
+				current = nil
+
+				if !defaultFound {
+					defaultFound = true
+					current = &defaultCredentials
+				}
+			case "login", "password", "account":
+				if j+1 >= len(tokens) {
+					continue
+				}
+
+				j++
+
+				if current == nil {
+					continue
+				}
+
+				if token == "login" {
+					current.UserName = tokens[j]
+				}
+
+				if token == "password" {
+					current.Password = tokens[j]
+				}
+			case "macdef":
+				// Macro definitions run until the next empty line
+				current = nil
+
+				for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+					i++
+				}
+
+				j = len(tokens)
+			}
+		}
+	}
+
+	if machineFound {
+		return machineCredentials, true
+	}
+
+	return defaultCredentials, defaultFound
+}
+
+func (provider *CommandCredentialsProvider) Retrieve(ctx context.Context) (BasicAuth, error) {
+	if provider.Command == "" {
+		return BasicAuth{}, NewCredentialsProviderError("command", ErrNoCredentials)
+	}
+
+	var stderr bytes.Buffer
+
+	command := exec.CommandContext(ctx, provider.Command, provider.Args...)
+	command.Stderr = &stderr
+
+	output, err := command.Output()
+
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			err = fmt.Errorf("%w: %s", err, message)
+		}
+
+		return BasicAuth{}, NewCredentialsProviderError("command", err)
+	}
+
+	var credentials BasicAuth
+
+	for _, line := range strings.Split(string(output), "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), "=", 2)
+
+		if len(parts) != 2 {
+			continue
+		}
+
+		switch parts[0] {
+		case "username":
+			credentials.UserName = parts[1]
+		case "password":
+			credentials.Password = parts[1]
+		}
+	}
+
+	if credentials.UserName == "" || credentials.Password == "" {
+		return BasicAuth{}, NewCredentialsProviderError("command", ErrNoCredentials)
+	}
+
+	return credentials, nil
+}
+
+// Retrieve returns the credentials of the first provider which has any. Providers returning ErrNoCredentials are
+// skipped, any other error is returned immediately.
+func (provider *ChainCredentialsProvider) Retrieve(ctx context.Context) (BasicAuth, error) {
+	for _, link := range provider.Providers {
+		credentials, err := link.Retrieve(ctx)
+
+		if err == nil {
+			return credentials, nil
+		}
+
+		if !errors.Is(err, ErrNoCredentials) {
+			return BasicAuth{}, err
+		}
+	}
+
+	return BasicAuth{}, NewCredentialsProviderError("chain", ErrNoCredentials)
+}
+
+func (provider *CachingCredentialsProvider) Retrieve(ctx context.Context) (BasicAuth, error) {
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+
+	if time.Now().Before(provider.expires) {
+		return provider.credentials, nil
+	}
+
+	credentials, err := provider.Provider.Retrieve(ctx)
+
+	if err != nil {
+		return BasicAuth{}, err
+	}
+
+	provider.credentials = credentials
+	provider.expires = time.Now().Add(provider.TTL)
+
+	return credentials, nil
+}
+
+// Expire forces the next call to Retrieve to consult the underlying provider.
+func (provider *CachingCredentialsProvider) Expire() {
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+
+	provider.expires = time.Time{}
+}
+
+func (client *Client) credentials(ctx context.Context) (BasicAuth, error) {
+	if client.CredentialsProvider == nil {
+		return client.Credentials, nil
+	}
+
+	return client.CredentialsProvider.Retrieve(ctx)
+}
@@ -0,0 +1,374 @@
+package go_tilaa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testPassword = "hunter2"
+
+var formatVerbs = []string{"%v", "%+v", "%#v", "%s", "%q", "%x", "%X", "%d", "%10s", "%-40q", "%.3s"}
+
+func TestBasicAuthFormatRedactsPassword(t *testing.T) {
+	credentials := BasicAuth{UserName: "api@example.com", Password: testPassword}
+
+	values := map[string]interface{}{
+		"value":   credentials,
+		"pointer": &credentials,
+		"client":  &Client{Credentials: credentials},
+		"slice":   []BasicAuth{credentials},
+	}
+
+	for name, value := range values {
+		for _, verb := range formatVerbs {
+			formatted := fmt.Sprintf(verb, value)
+
+			if strings.Contains(formatted, testPassword) || strings.Contains(formatted, fmt.Sprintf("%x", testPassword)) {
+				t.Errorf("%s formatted with %s contains the password: %s", name, verb, formatted)
+			}
+		}
+	}
+}
+
+func TestBasicAuthFormatHonoursVerb(t *testing.T) {
+	credentials := BasicAuth{UserName: "u", Password: testPassword}
+	redacted := "{UserName:u Password:********}"
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"%v", redacted},
+		{"%+v", redacted},
+		{"%s", redacted},
+		{"%d", redacted},
+		{"%q", `"` + redacted + `"`},
+		{"%x", fmt.Sprintf("%x", redacted)},
+		{"%X", fmt.Sprintf("%X", redacted)},
+		{"%35s", "     " + redacted},
+		{"%-35s|", redacted + "     |"},
+		{"%.9s", "{UserName"},
+		{"%#v", `go_tilaa.BasicAuth{UserName:"u", Password:"********"}`},
+	}
+
+	for _, test := range tests {
+		if formatted := fmt.Sprintf(test.format, credentials); formatted != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.format, test.expected, formatted)
+		}
+	}
+}
+
+func TestBasicAuthFormatWithoutPassword(t *testing.T) {
+	if formatted := fmt.Sprint(BasicAuth{UserName: "u"}); formatted != "{UserName:u Password:}" {
+		t.Errorf("unexpected %q", formatted)
+	}
+}
+
+func TestCachingCredentialsProviderFormatRedactsPassword(t *testing.T) {
+	provider := NewCachingCredentialsProvider(NewStaticCredentialsProvider("u", testPassword), time.Minute)
+
+	if _, err := provider.Retrieve(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	client := &Client{CredentialsProvider: provider}
+
+	for _, verb := range formatVerbs {
+		for _, value := range []interface{}{provider, client} {
+			formatted := fmt.Sprintf(verb, value)
+
+			if strings.Contains(formatted, testPassword) || strings.Contains(formatted, fmt.Sprintf("%x", testPassword)) {
+				t.Errorf("%s contains the password: %s", verb, formatted)
+			}
+		}
+	}
+
+	if formatted := fmt.Sprintf("%v", provider); formatted != "{Provider:&{{UserName:u Password:********}} TTL:1m0s}" {
+		t.Errorf("unexpected %q", formatted)
+	}
+}
+
+func TestErrorsDoNotContainPassword(t *testing.T) {
+	credentials := BasicAuth{UserName: "u", Password: testPassword}
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusUnauthorized)
+		_, _ = writer.Write([]byte(`{"status":"error","message":"Authentication failed"}`))
+	}))
+	defer server.Close()
+
+	request, _ := http.NewRequest(http.MethodGet, server.URL+"/virtual_machines", nil)
+	request.SetBasicAuth(credentials.UserName, credentials.Password)
+
+	response, err := http.DefaultClient.Do(request)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer response.Body.Close()
+
+	responseError := NewApiResponseError(request, response)
+	invalidCredentials := NewInvalidCredentialsError(credentials)
+	invalidCredentials.response = responseError
+
+	tests := map[string]error{
+		"ApiResponseError":         responseError,
+		"InvalidCredentialsError":  invalidCredentials,
+		"CredentialsProviderError": NewCredentialsProviderError("static", fmt.Errorf("rejected %v", credentials)),
+		"wrapped":                  fmt.Errorf("request failed: %w", invalidCredentials),
+	}
+
+	for name, err := range tests {
+		for _, formatted := range []string{err.Error(), fmt.Sprintf("%+v", err), fmt.Sprintf("%#v", err)} {
+			if strings.Contains(formatted, testPassword) {
+				t.Errorf("%s contains the password: %s", name, formatted)
+			}
+		}
+	}
+
+	if !errors.Is(invalidCredentials, ErrUnauthorized) {
+		t.Error("InvalidCredentialsError does not match ErrUnauthorized")
+	}
+
+	if message := invalidCredentials.Error(); message != "Invalid API Credentials: Username (u) or Password invalid" {
+		t.Errorf("unexpected message %q", message)
+	}
+
+	if message := responseError.Error(); !strings.Contains(message, "Authentication failed") {
+		t.Errorf("unexpected message %q", message)
+	}
+}
+
+func writeCredentialsFile(t *testing.T, contents string) string {
+	file, err := ioutil.TempFile("", "credentials")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer file.Close()
+
+	if _, err := file.WriteString(contents); err != nil {
+		t.Fatal(err)
+	}
+
+	return file.Name()
+}
+
+// countingCredentialsProvider counts how often its credentials are retrieved.
+type countingCredentialsProvider struct {
+	credentials BasicAuth
+	err         error
+	calls       int
+}
+
+func (provider *countingCredentialsProvider) Retrieve(ctx context.Context) (BasicAuth, error) {
+	provider.calls++
+
+	return provider.credentials, provider.err
+}
+
+func TestEnvCredentialsProvider(t *testing.T) {
+	provider := &EnvCredentialsProvider{UserNameVariable: "TILAA_TEST_USERNAME", PasswordVariable: "TILAA_TEST_PASSWORD"}
+
+	defer os.Unsetenv(provider.UserNameVariable)
+	defer os.Unsetenv(provider.PasswordVariable)
+
+	_ = os.Setenv(provider.UserNameVariable, "api@example.com")
+
+	if _, err := provider.Retrieve(context.Background()); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("expected ErrNoCredentials without a password, got %v", err)
+	}
+
+	_ = os.Setenv(provider.PasswordVariable, testPassword)
+
+	credentials, err := provider.Retrieve(context.Background())
+
+	if err != nil || credentials != (BasicAuth{UserName: "api@example.com", Password: testPassword}) {
+		t.Errorf("unexpected credentials %v and %v", credentials, err)
+	}
+}
+
+func TestConfigFileCredentialsProvider(t *testing.T) {
+	path := writeCredentialsFile(t, `# tilaa credentials
+[default]
+username = api@example.com
+password = `+testPassword+`
+
+; staging account
+[profile staging]
+UserName=staging@example.com
+password=staging
+
+[incomplete]
+username = nobody
+`)
+	defer os.Remove(path)
+
+	malformed := writeCredentialsFile(t, "[default]\nusername api@example.com\n")
+	defer os.Remove(malformed)
+
+	tests := []struct {
+		path     string
+		profile  string
+		expected BasicAuth
+		none     bool
+	}{
+		{path, "", BasicAuth{UserName: "api@example.com", Password: testPassword}, false},
+		{path, "staging", BasicAuth{UserName: "staging@example.com", Password: "staging"}, false},
+		{path, "incomplete", BasicAuth{}, true},
+		{path, "missing", BasicAuth{}, true},
+		{path + ".missing", "", BasicAuth{}, true},
+		{malformed, "", BasicAuth{}, false},
+	}
+
+	for _, test := range tests {
+		credentials, err := NewConfigFileCredentialsProvider(test.path, test.profile).Retrieve(context.Background())
+
+		if errors.Is(err, ErrNoCredentials) != test.none || credentials != test.expected {
+			t.Errorf("%s [%s]: got %v and %v", test.path, test.profile, credentials, err)
+		}
+
+		if test.path == malformed && (err == nil || !strings.Contains(err.Error(), ":2: expected key = value")) {
+			t.Errorf("expected the malformed line to be reported, got %v", err)
+		}
+	}
+}
+
+func TestNetrcCredentialsProvider(t *testing.T) {
+	path := writeCredentialsFile(t, `machine example.com login other password other
+
+machine api.tilaa.com
+	login api@example.com
+	password `+testPassword+`
+
+macdef init
+machine ignored.example.com login macro password macro
+
+default login fallback password fallback
+`)
+	defer os.Remove(path)
+
+	tests := map[string]BasicAuth{
+		"api.tilaa.com":       {UserName: "api@example.com", Password: testPassword},
+		"example.com":         {UserName: "other", Password: "other"},
+		"ignored.example.com": {UserName: "fallback", Password: "fallback"},
+	}
+
+	for machine, expected := range tests {
+		credentials, err := (&NetrcCredentialsProvider{Path: path, Machine: machine}).Retrieve(context.Background())
+
+		if err != nil || credentials != expected {
+			t.Errorf("%s: got %v and %v", machine, credentials, err)
+		}
+	}
+
+	empty := writeCredentialsFile(t, "machine example.com login other password other\n")
+	defer os.Remove(empty)
+
+	if _, err := (&NetrcCredentialsProvider{Path: empty, Machine: "api.tilaa.com"}).Retrieve(context.Background()); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("expected ErrNoCredentials without an entry, got %v", err)
+	}
+}
+
+func TestCommandCredentialsProvider(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	credentials, err := NewCommandCredentialsProvider("sh", "-c", "echo username=api@example.com; echo password="+testPassword).
+		Retrieve(context.Background())
+
+	if err != nil || credentials != (BasicAuth{UserName: "api@example.com", Password: testPassword}) {
+		t.Errorf("unexpected credentials %v and %v", credentials, err)
+	}
+
+	_, err = NewCommandCredentialsProvider("sh", "-c", "echo 'vault is sealed' >&2; exit 1").Retrieve(context.Background())
+
+	if err == nil || !strings.Contains(err.Error(), "vault is sealed") || errors.Is(err, ErrNoCredentials) {
+		t.Errorf("expected the standard error of the helper in the error, got %v", err)
+	}
+
+	if _, err := NewCommandCredentialsProvider("sh", "-c", "echo username=api@example.com").Retrieve(context.Background()); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("expected ErrNoCredentials without a password, got %v", err)
+	}
+
+	if _, err := NewCommandCredentialsProvider("").Retrieve(context.Background()); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("expected ErrNoCredentials without a command, got %v", err)
+	}
+}
+
+func TestChainCredentialsProvider(t *testing.T) {
+	none := &countingCredentialsProvider{err: NewCredentialsProviderError("env", ErrNoCredentials)}
+	broken := &countingCredentialsProvider{err: errors.New("permission denied")}
+	static := NewStaticCredentialsProvider("api@example.com", testPassword)
+
+	credentials, err := NewChainCredentialsProvider(none, static, broken).Retrieve(context.Background())
+
+	if err != nil || credentials.UserName != "api@example.com" || broken.calls != 0 {
+		t.Errorf("expected the first provider with credentials to be used, got %v and %v", credentials, err)
+	}
+
+	if _, err := NewChainCredentialsProvider(none, broken, static).Retrieve(context.Background()); err == nil || errors.Is(err, ErrNoCredentials) {
+		t.Errorf("expected the error of a failing provider, got %v", err)
+	}
+
+	if _, err := NewChainCredentialsProvider(none, none).Retrieve(context.Background()); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("expected ErrNoCredentials, got %v", err)
+	}
+}
+
+func TestCachingCredentialsProvider(t *testing.T) {
+	counting := &countingCredentialsProvider{credentials: BasicAuth{UserName: "u", Password: testPassword}}
+	provider := NewCachingCredentialsProvider(counting, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		if _, err := provider.Retrieve(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if counting.calls != 1 {
+		t.Errorf("expected the credentials to be retrieved once, got %d", counting.calls)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusUnauthorized)
+		_, _ = writer.Write([]byte(`{"status":"error","message":"Authentication failed"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewWithOptions(WithBaseUrl(server.URL), WithCredentialsProvider(provider), WithRetryPolicy(NoRetryPolicy))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.Site.List(); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected the request to be unauthorized, got %v", err)
+	}
+
+	if _, err := provider.Retrieve(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if counting.calls != 2 {
+		t.Errorf("expected rejected credentials to be retrieved again, got %d calls", counting.calls)
+	}
+}
+
+func TestDefaultClientCachesCredentials(t *testing.T) {
+	if _, ok := DefaultClient.CredentialsProvider.(*CachingCredentialsProvider); !ok {
+		t.Errorf("expected DefaultClient to cache its credentials, got %T", DefaultClient.CredentialsProvider)
+	}
+}
@@ -11,16 +11,17 @@ func SetBasicAuth(username string, password string) {
 	DefaultClient.SetBasicAuth(username, password)
 }
 
-func createClient(username string, password string) *Client {
+func createClient() *Client {
 	httpClient := &http.Client{}
 
 	client := &Client{
 		httpClient: httpClient,
 	}
 
-	client.SetBasicAuth(username, password)
 	client.BaseUrl, _ = url.Parse(BaseUrl)
+	client.ApiVersion = ApiVersion
 	client.UserAgent = UserAgent + "/" + ApiVersion
+	client.RetryPolicy = DefaultRetryPolicy
 
 	client.VirtualMachine = &VirtualMachineService{client: client}
 	client.Snapshot = &SnapshotService{client: client}
@@ -34,5 +35,7 @@ func createClient(username string, password string) *Client {
 }
 
 func init() {
-	DefaultClient = New("", "")
+	provider := NewCachingCredentialsProvider(DefaultCredentialsProvider(), DefaultCredentialsTtl)
+
+	DefaultClient, _ = NewWithOptions(WithCredentialsProvider(provider))
 }
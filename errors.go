@@ -1,25 +1,59 @@
 package go_tilaa
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"strings"
 	"time"
 )
 
+// Sentinel errors matched by ApiResponseError and InvalidCredentialsError through errors.Is.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrConflict     = errors.New("conflict")
+	ErrRateLimited  = errors.New("rate limited")
+)
+
+// maxErrorBodySize limits how much of a non-200 response body is retained in an ApiResponseError.
+const maxErrorBodySize = 64 * 1024
+
 type ApiError struct {
 	reason string
 }
 
 type ApiRequestError struct {
 	reason string
+	cause  error
+}
+
+// ApiResponseError is returned when the API responds with a non-200 status.
+type ApiResponseError struct {
+	StatusCode int
+	Status     string
+	Method     string
+	Path       string
+	Message    string
+	Body       []byte
 }
 
 type ClientError struct {
 	reason string
 }
 
+// InvalidCredentialsError only retains the username, so the password can never end up in formatted output.
 type InvalidCredentialsError struct {
-	credentials BasicAuth
+	userName string
+	response *ApiResponseError
+}
+
+type CredentialsProviderError struct {
+	provider string
+	cause    error
 }
 
 type ResultsDecoderError struct {
@@ -27,10 +61,26 @@ type ResultsDecoderError struct {
 	response         *http.Response
 }
 
+// FieldError describes a single invalid field of a ValidationError.
+type FieldError struct {
+	Field  string
+	Reason string
+}
+
+// ValidationError lists every invalid field found while validating a resource.
+type ValidationError struct {
+	Errors []FieldError
+}
+
 type InvalidTaskError struct {
 	task string
 }
 
+type TaskNotAllowedError struct {
+	task   string
+	status VirtualMachineStatus
+}
+
 type InvalidCancelDateError struct {
 	date *time.Time
 }
@@ -38,9 +88,32 @@ type InvalidCancelDateError struct {
 type VirtualMachineNotCreatedError struct {
 }
 
+type VirtualMachineFailedError struct {
+	status VirtualMachineStatus
+}
+
+type WaitTimeoutError struct {
+	status   VirtualMachineStatus
+	expected []VirtualMachineStatus
+	cause    error
+}
+
 type VirtualMachineNotCancelledError struct {
 }
 
+type SnapshotFailedError struct {
+	name   string
+	status SnapshotStatus
+}
+
+// SnapshotWaitTimeoutError is returned when a snapshot did not appear or finish in time. The status is empty when the
+// snapshot was never found.
+type SnapshotWaitTimeoutError struct {
+	name   string
+	status SnapshotStatus
+	cause  error
+}
+
 type SnapshotNotCreatedError struct {
 }
 
@@ -50,21 +123,41 @@ type MetadataNotCreatedError struct {
 type SshKeyNotCreatedError struct {
 }
 
+// SshKeyNotResolvedError is returned when a key was added but could not be found afterwards.
+type SshKeyNotResolvedError struct {
+	label string
+}
+
+// InvalidPublicKeyError is returned when an OpenSSH public key is malformed, unsupported or too weak.
+type InvalidPublicKeyError struct {
+	reason string
+}
+
 var _ error = &ApiError{}
 var _ error = &ApiRequestError{}
+var _ error = &ApiResponseError{}
 var _ error = &ClientError{}
 
 var _ error = &InvalidCredentialsError{}
+var _ error = &CredentialsProviderError{}
 var _ error = &ResultsDecoderError{}
 
+var _ error = &ValidationError{}
 var _ error = &InvalidTaskError{}
+var _ error = &TaskNotAllowedError{}
 var _ error = &InvalidCancelDateError{}
 
 var _ error = &VirtualMachineNotCreatedError{}
 var _ error = &VirtualMachineNotCancelledError{}
+var _ error = &VirtualMachineFailedError{}
+var _ error = &WaitTimeoutError{}
+var _ error = &SnapshotFailedError{}
+var _ error = &SnapshotWaitTimeoutError{}
 var _ error = &SnapshotNotCreatedError{}
 var _ error = &MetadataNotCreatedError{}
 var _ error = &SshKeyNotCreatedError{}
+var _ error = &SshKeyNotResolvedError{}
+var _ error = &InvalidPublicKeyError{}
 
 func NewApiError(reason string) *ApiError {
 	return &ApiError{reason: reason}
@@ -74,22 +167,63 @@ func NewApiRequestError(reason string) *ApiRequestError {
 	return &ApiRequestError{reason: reason}
 }
 
+func WrapApiRequestError(cause error) *ApiRequestError {
+	return &ApiRequestError{reason: cause.Error(), cause: cause}
+}
+
+func NewApiResponseError(request *http.Request, response *http.Response) *ApiResponseError {
+	responseError := &ApiResponseError{
+		StatusCode: response.StatusCode,
+		Status:     response.Status,
+		Method:     request.Method,
+		Path:       request.URL.Path,
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(response.Body, maxErrorBodySize))
+
+	if err != nil {
+		return responseError
+	}
+
+	responseError.Body = body
+
+	var statusResponse StatusResponse
+
+	if json.Unmarshal(body, &statusResponse) == nil {
+		responseError.Message = statusResponse.Message
+	}
+
+	return responseError
+}
+
 func NewClientError(reason string) *ClientError {
 	return &ClientError{reason: reason}
 }
 
 func NewInvalidCredentialsError(credentials BasicAuth) *InvalidCredentialsError {
-	return &InvalidCredentialsError{credentials: credentials}
+	return &InvalidCredentialsError{userName: credentials.UserName}
+}
+
+func NewCredentialsProviderError(provider string, cause error) *CredentialsProviderError {
+	return &CredentialsProviderError{provider: provider, cause: cause}
 }
 
 func NewResultsDecoderError(jsonDecoderError error, response *http.Response) *ResultsDecoderError {
 	return &ResultsDecoderError{jsonDecoderError: jsonDecoderError, response: response}
 }
 
+func NewValidationError() *ValidationError {
+	return &ValidationError{}
+}
+
 func NewInvalidTaskError(task string) *InvalidTaskError {
 	return &InvalidTaskError{task: task}
 }
 
+func NewTaskNotAllowedError(task string, status VirtualMachineStatus) *TaskNotAllowedError {
+	return &TaskNotAllowedError{task: task, status: status}
+}
+
 func NewInvalidCancelDateError(date *time.Time) *InvalidCancelDateError {
 	return &InvalidCancelDateError{date: date}
 }
@@ -102,6 +236,22 @@ func NewVirtualMachineNotCancelledError() *VirtualMachineNotCancelledError {
 	return &VirtualMachineNotCancelledError{}
 }
 
+func NewVirtualMachineFailedError(status VirtualMachineStatus) *VirtualMachineFailedError {
+	return &VirtualMachineFailedError{status: status}
+}
+
+func NewWaitTimeoutError(status VirtualMachineStatus, expected []VirtualMachineStatus, cause error) *WaitTimeoutError {
+	return &WaitTimeoutError{status: status, expected: expected, cause: cause}
+}
+
+func NewSnapshotFailedError(name string, status SnapshotStatus) *SnapshotFailedError {
+	return &SnapshotFailedError{name: name, status: status}
+}
+
+func NewSnapshotWaitTimeoutError(name string, status SnapshotStatus, cause error) *SnapshotWaitTimeoutError {
+	return &SnapshotWaitTimeoutError{name: name, status: status, cause: cause}
+}
+
 func NewSnapshotNotCreatedError() *SnapshotNotCreatedError {
 	return &SnapshotNotCreatedError{}
 }
@@ -114,6 +264,14 @@ func NewSshKeyNotCreatedError() *SshKeyNotCreatedError {
 	return &SshKeyNotCreatedError{}
 }
 
+func NewSshKeyNotResolvedError(label string) *SshKeyNotResolvedError {
+	return &SshKeyNotResolvedError{label: label}
+}
+
+func NewInvalidPublicKeyError(reason string) *InvalidPublicKeyError {
+	return &InvalidPublicKeyError{reason: reason}
+}
+
 func (error *ApiError) Error() string {
 	return fmt.Sprintf("API Error: %s", error.reason)
 }
@@ -122,22 +280,123 @@ func (error *ApiRequestError) Error() string {
 	return fmt.Sprintf("API Request Error: %s", error.reason)
 }
 
+func (error *ApiRequestError) Unwrap() error {
+	return error.cause
+}
+
+func (error *ApiResponseError) Error() string {
+	message := error.Message
+
+	if message == "" {
+		message = "Request returned with non-200 status"
+	}
+
+	return fmt.Sprintf("API Response Error: [%s] %s %s: %s", error.Status, error.Method, error.Path, message)
+}
+
+func (error *ApiResponseError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return error.StatusCode == http.StatusNotFound
+	case ErrUnauthorized:
+		return error.StatusCode == http.StatusUnauthorized
+	case ErrConflict:
+		return error.StatusCode == http.StatusConflict
+	case ErrRateLimited:
+		return error.StatusCode == http.StatusTooManyRequests
+	}
+
+	return false
+}
+
+// BodyString returns the raw response body, which is useful when the API did not return a JSON status message.
+func (error *ApiResponseError) BodyString() string {
+	return strings.TrimSpace(string(error.Body))
+}
+
 func (error *ClientError) Error() string {
 	return fmt.Sprintf("Client Error: %s", error.reason)
 }
 
 func (error *InvalidCredentialsError) Error() string {
-	return fmt.Sprintf("Invalid API Credentials: Username (%s) or Password (%s) invalid", error.credentials.UserName, error.credentials.Password)
+	return fmt.Sprintf("Invalid API Credentials: Username (%s) or Password invalid", error.userName)
+}
+
+func (error *InvalidCredentialsError) Is(target error) bool {
+	return target == ErrUnauthorized
+}
+
+func (error *InvalidCredentialsError) Unwrap() error {
+	if error.response == nil {
+		return nil
+	}
+
+	return error.response
+}
+
+func (error *CredentialsProviderError) Error() string {
+	return fmt.Sprintf("Credentials Provider Error: %s (%s)", error.provider, error.cause.Error())
+}
+
+func (error *CredentialsProviderError) Unwrap() error {
+	return error.cause
 }
 
 func (error *ResultsDecoderError) Error() string {
 	return fmt.Sprintf("Results Decoder Error: Unable to decode result (%s)", error.jsonDecoderError.Error())
 }
 
+func (error *ResultsDecoderError) Unwrap() error {
+	return error.jsonDecoderError
+}
+
+func (error *ResultsDecoderError) Response() *http.Response {
+	return error.response
+}
+
+func (error *ValidationError) Error() string {
+	reasons := make([]string, len(error.Errors))
+
+	for i, fieldError := range error.Errors {
+		reasons[i] = fieldError.String()
+	}
+
+	return fmt.Sprintf("Validation Error: %s", strings.Join(reasons, "; "))
+}
+
+func (error *ValidationError) Add(field string, format string, args ...interface{}) {
+	error.Errors = append(error.Errors, FieldError{Field: field, Reason: fmt.Sprintf(format, args...)})
+}
+
+func (error *ValidationError) HasErrors() bool {
+	return len(error.Errors) > 0
+}
+
+// Field returns the errors of the given field.
+func (error *ValidationError) Field(field string) []FieldError {
+	var fieldErrors []FieldError
+
+	for _, fieldError := range error.Errors {
+		if fieldError.Field == field {
+			fieldErrors = append(fieldErrors, fieldError)
+		}
+	}
+
+	return fieldErrors
+}
+
+func (error FieldError) String() string {
+	return fmt.Sprintf("%s %s", error.Field, error.Reason)
+}
+
 func (error *InvalidTaskError) Error() string {
 	return fmt.Sprintf("Invalid Task: %s", error.task)
 }
 
+func (error *TaskNotAllowedError) Error() string {
+	return fmt.Sprintf("Task Not Allowed: %s can not be run while Virtual Machine is %s", error.task, error.status)
+}
+
 func (error *InvalidCancelDateError) Error() string {
 	return fmt.Sprintf("Invalid Cancel Date: %s", error.date.String())
 }
@@ -150,6 +409,50 @@ func (error *VirtualMachineNotCancelledError) Error() string {
 	return fmt.Sprintf("Virtual Machine was not cancelled.")
 }
 
+func (error *VirtualMachineFailedError) Error() string {
+	return fmt.Sprintf("Virtual Machine entered failed status: %s", error.status)
+}
+
+func (error *VirtualMachineFailedError) Status() VirtualMachineStatus {
+	return error.status
+}
+
+func (error *WaitTimeoutError) Error() string {
+	return fmt.Sprintf("Timed out waiting for Virtual Machine status %v, last observed status: %s", error.expected, error.status)
+}
+
+func (error *WaitTimeoutError) Status() VirtualMachineStatus {
+	return error.status
+}
+
+func (error *WaitTimeoutError) Unwrap() error {
+	return error.cause
+}
+
+func (error *SnapshotFailedError) Error() string {
+	return fmt.Sprintf("Snapshot %s entered failed status: %s", error.name, error.status)
+}
+
+func (error *SnapshotFailedError) Status() SnapshotStatus {
+	return error.status
+}
+
+func (error *SnapshotWaitTimeoutError) Error() string {
+	if error.status == "" {
+		return fmt.Sprintf("Timed out waiting for Snapshot %s to appear", error.name)
+	}
+
+	return fmt.Sprintf("Timed out waiting for Snapshot %s to finish, last observed status: %s", error.name, error.status)
+}
+
+func (error *SnapshotWaitTimeoutError) Status() SnapshotStatus {
+	return error.status
+}
+
+func (error *SnapshotWaitTimeoutError) Unwrap() error {
+	return error.cause
+}
+
 func (error *SnapshotNotCreatedError) Error() string {
 	return fmt.Sprintf("Snapshot has not been created yet.")
 }
@@ -161,3 +464,16 @@ func (error *MetadataNotCreatedError) Error() string {
 func (error *SshKeyNotCreatedError) Error() string {
 	return fmt.Sprintf("SshKey has not been created yet.")
 }
+
+func (error *SshKeyNotResolvedError) Error() string {
+	return fmt.Sprintf("SshKey %s was added but could not be found.", error.label)
+}
+
+func (error *InvalidPublicKeyError) Error() string {
+	return fmt.Sprintf("Invalid Public Key: %s", error.reason)
+}
+
+// Reason returns the problem found, without the prefix of Error.
+func (error *InvalidPublicKeyError) Reason() string {
+	return error.reason
+}
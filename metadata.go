@@ -1,9 +1,11 @@
 package go_tilaa
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -11,10 +13,15 @@ const metadataBasePath = "metadata"
 
 type MetadataServiceInterface interface {
 	List() (*[]Metadata, error)
+	ListWithContext(context.Context) (*[]Metadata, error)
 	Add(*Metadata) (*Metadata, error)
+	AddWithContext(context.Context, *Metadata) (*Metadata, error)
 	View(int) (*Metadata, error)
+	ViewWithContext(context.Context, int) (*Metadata, error)
 	Edit(*Metadata) (*Metadata, error)
+	EditWithContext(context.Context, *Metadata) (*Metadata, error)
 	Delete(*Metadata) error
+	DeleteWithContext(context.Context, *Metadata) error
 }
 
 type MetadataService struct {
@@ -52,9 +59,13 @@ type NewMetadataResponse struct {
 }
 
 func (service *MetadataService) List() (*[]Metadata, error) {
+	return service.ListWithContext(context.Background())
+}
+
+func (service *MetadataService) ListWithContext(ctx context.Context) (*[]Metadata, error) {
 	var response MetadatasResponse
 
-	_, err := service.client.Get(metadataBasePath, &response)
+	_, err := service.client.GetWithContext(ctx, metadataBasePath, &response)
 
 	if err != nil {
 		return nil, err
@@ -74,6 +85,10 @@ func (service *MetadataService) List() (*[]Metadata, error) {
 }
 
 func (service *MetadataService) Add(metadata *Metadata) (*Metadata, error) {
+	return service.AddWithContext(context.Background(), metadata)
+}
+
+func (service *MetadataService) AddWithContext(ctx context.Context, metadata *Metadata) (*Metadata, error) {
 	if err := metadata.Validate(); err != nil {
 		return NewMetadata(service.client), err
 	}
@@ -82,7 +97,7 @@ func (service *MetadataService) Add(metadata *Metadata) (*Metadata, error) {
 
 	var response NewMetadataResponse
 
-	_, err := service.client.Post(metadataBasePath, payload, &response)
+	_, err := service.client.PostWithContext(ctx, metadataBasePath, payload, &response)
 
 	if err != nil {
 		return NewMetadata(service.client), err
@@ -98,9 +113,13 @@ func (service *MetadataService) Add(metadata *Metadata) (*Metadata, error) {
 }
 
 func (service *MetadataService) View(metadataId int) (*Metadata, error) {
+	return service.ViewWithContext(context.Background(), metadataId)
+}
+
+func (service *MetadataService) ViewWithContext(ctx context.Context, metadataId int) (*Metadata, error) {
 	var response MetadataResponse
 
-	_, err := service.client.Get(service.path(strconv.Itoa(metadataId)), &response)
+	_, err := service.client.GetWithContext(ctx, service.path(strconv.Itoa(metadataId)), &response)
 
 	if err != nil {
 		return NewMetadata(service.client), err
@@ -118,6 +137,10 @@ func (service *MetadataService) View(metadataId int) (*Metadata, error) {
 }
 
 func (service *MetadataService) Edit(metadata *Metadata) (*Metadata, error) {
+	return service.EditWithContext(context.Background(), metadata)
+}
+
+func (service *MetadataService) EditWithContext(ctx context.Context, metadata *Metadata) (*Metadata, error) {
 	if err := metadata.Validate(); err != nil {
 		return metadata, err
 	}
@@ -126,7 +149,7 @@ func (service *MetadataService) Edit(metadata *Metadata) (*Metadata, error) {
 
 	var response StatusResponse
 
-	_, err := service.client.Post(service.path(strconv.Itoa(metadata.Id)), payload, &response)
+	_, err := service.client.PostWithContext(ctx, service.path(strconv.Itoa(metadata.Id)), payload, &response)
 
 	if err != nil {
 		return metadata, err
@@ -140,9 +163,13 @@ func (service *MetadataService) Edit(metadata *Metadata) (*Metadata, error) {
 }
 
 func (service *MetadataService) Delete(metadata *Metadata) error {
+	return service.DeleteWithContext(context.Background(), metadata)
+}
+
+func (service *MetadataService) DeleteWithContext(ctx context.Context, metadata *Metadata) error {
 	var response StatusResponse
 
-	_, err := service.client.Delete(service.path(strconv.Itoa(metadata.Id)), &response)
+	_, err := service.client.DeleteWithContext(ctx, service.path(strconv.Itoa(metadata.Id)), &response)
 
 	if err != nil {
 		return err
@@ -192,11 +219,29 @@ func (metadata *Metadata) Payload() *url.Values {
 	}
 }
 
+// Validate checks that the name is set and that the user data fits the size limit and, for cloud-config, is valid YAML.
+// It returns a ValidationError listing every problem found. Use ValidateUserData to check the user data strictly.
 func (metadata *Metadata) Validate() error {
-	// TODO: Implement validation for all the fields
+	validationError := NewValidationError()
+
+	if strings.TrimSpace(metadata.Name) == "" {
+		validationError.Add("name", "can not be empty")
+	}
+
+	validateUserData(validationError, "user_data", metadata.UserData, false)
+
+	if validationError.HasErrors() {
+		return validationError
+	}
+
 	return nil
 }
 
 func NewMetadata(client *Client) *Metadata {
 	return &Metadata{client: client}
 }
+
+// SetClient binds the metadata to the given client, which is used by methods such as Commit and Delete.
+func (metadata *Metadata) SetClient(client *Client) {
+	metadata.client = client
+}
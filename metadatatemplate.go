@@ -0,0 +1,231 @@
+package go_tilaa
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// MetadataTemplate renders the name and user data of Metadata per machine with text/template, so machines which only
+// differ by hostname, role or address can share a single template:
+//
+//	metadataTemplate, err := go_tilaa.NewMetadataTemplate(client, "{{.Name}}-init", "#cloud-config\nhostname: {{.Hostname}}\n")
+//
+//	machine, err := go_tilaa.NewVirtualMachineSpec(client).WithName("web1").WithTemplateName("Ubuntu 20.04").Build(ctx)
+//	metadataId, err := metadataTemplate.Apply(ctx, machine, map[string]interface{}{"role": "web"})
+//
+//	machine.Metadata = go_tilaa.ResourceId(metadataId)
+//	machine, err = client.VirtualMachine.AddWithContext(ctx, machine)
+//
+// Referencing a variable which was not supplied is an error.
+type MetadataTemplate struct {
+	client *Client
+
+	name     *template.Template
+	userData *template.Template
+}
+
+// MetadataTemplateData is passed to the templates. Addresses are only known once a machine has been created.
+type MetadataTemplateData struct {
+	Name     string
+	DnsName  string
+	Hostname string
+	Ipv4     string
+	Ipv6     string
+	Site     string
+	Template string
+
+	// Addresses lists every address of the machine, IPv4 addresses first.
+	Addresses []string
+
+	// Vars holds the values supplied to Render or Apply, e.g. {{.Vars.role}}.
+	Vars map[string]interface{}
+
+	Machine *VirtualMachine
+}
+
+// metadataTemplateFuncs are available in metadata templates next to the builtin functions of text/template.
+var metadataTemplateFuncs = template.FuncMap{
+	"join":   strings.Join,
+	"quote":  strconv.Quote,
+	"indent": indent,
+}
+
+func NewMetadataTemplate(client *Client, name string, userData string) (*MetadataTemplate, error) {
+	nameTemplate, err := parseMetadataTemplate("name", name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	userDataTemplate, err := parseMetadataTemplate("user_data", userData)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &MetadataTemplate{client: client, name: nameTemplate, userData: userDataTemplate}, nil
+}
+
+func parseMetadataTemplate(field string, text string) (*template.Template, error) {
+	parsed, err := template.New(field).Funcs(metadataTemplateFuncs).Option("missingkey=error").Parse(text)
+
+	if err != nil {
+		return nil, NewClientError(fmt.Sprintf("invalid metadata template: %s", err.Error()))
+	}
+
+	return parsed, nil
+}
+
+// NewMetadataTemplateData collects the variables of the machine.
+func NewMetadataTemplateData(machine *VirtualMachine, vars map[string]interface{}) *MetadataTemplateData {
+	data := &MetadataTemplateData{
+		Name:     machine.Name,
+		DnsName:  machine.DnsName(),
+		Hostname: machine.Name,
+		Site:     machine.Site.Name,
+		Template: machine.Template.Name,
+		Vars:     vars,
+		Machine:  machine,
+	}
+
+	if data.DnsName != "" {
+		data.Hostname = strings.SplitN(data.DnsName, ".", 2)[0]
+	}
+
+	if data.Vars == nil {
+		data.Vars = map[string]interface{}{}
+	}
+
+	var ipv6 []string
+
+	for _, network := range machine.Network {
+		if network.Address == nil {
+			continue
+		}
+
+		switch network.Family {
+		case NetworkFamilyIpv4:
+			if data.Ipv4 == "" {
+				data.Ipv4 = network.Address.String()
+			}
+
+			data.Addresses = append(data.Addresses, network.Address.String())
+		case NetworkFamilyIpv6:
+			if data.Ipv6 == "" {
+				data.Ipv6 = network.Address.String()
+			}
+
+			ipv6 = append(ipv6, network.Address.String())
+		}
+	}
+
+	data.Addresses = append(data.Addresses, ipv6...)
+
+	return data
+}
+
+// Render renders and validates the Metadata for the machine, without saving it.
+func (metadataTemplate *MetadataTemplate) Render(machine *VirtualMachine, vars map[string]interface{}) (*Metadata, error) {
+	data := NewMetadataTemplateData(machine, vars)
+	metadata := NewMetadata(metadataTemplate.client)
+
+	var buffer bytes.Buffer
+
+	if err := metadataTemplate.name.Execute(&buffer, data); err != nil {
+		return nil, NewClientError(fmt.Sprintf("rendering metadata name: %s", err.Error()))
+	}
+
+	metadata.Name = strings.TrimSpace(buffer.String())
+	buffer.Reset()
+
+	if err := metadataTemplate.userData.Execute(&buffer, data); err != nil {
+		return nil, NewClientError(fmt.Sprintf("rendering metadata user data: %s", err.Error()))
+	}
+
+	metadata.UserData = buffer.String()
+
+	if err := metadata.Validate(); err != nil {
+		return nil, err
+	}
+
+	return metadata, nil
+}
+
+// Apply renders the Metadata for the machine and stores it, returning its ID. Metadata with the rendered name is
+// updated when its user data differs, otherwise new Metadata is added.
+func (metadataTemplate *MetadataTemplate) Apply(ctx context.Context, machine *VirtualMachine, vars map[string]interface{}) (int, error) {
+	metadata, err := metadataTemplate.Render(machine, vars)
+
+	if err != nil {
+		return 0, err
+	}
+
+	existing, err := metadataTemplate.find(ctx, metadata.Name)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if existing == nil {
+		added, err := metadataTemplate.client.Metadata.AddWithContext(ctx, metadata)
+
+		if err != nil {
+			return 0, err
+		}
+
+		return added.Id, nil
+	}
+
+	if existing.UserData != metadata.UserData {
+		existing.UserData = metadata.UserData
+
+		if _, err := metadataTemplate.client.Metadata.EditWithContext(ctx, existing); err != nil {
+			return 0, err
+		}
+	}
+
+	return existing.Id, nil
+}
+
+// find returns the Metadata with the given name, or nil when there is none.
+func (metadataTemplate *MetadataTemplate) find(ctx context.Context, name string) (*Metadata, error) {
+	metadata, err := metadataTemplate.client.Metadata.ListWithContext(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var found *Metadata
+
+	for i := range *metadata {
+		if (*metadata)[i].Name != name {
+			continue
+		}
+
+		if found != nil {
+			return nil, NewClientError(fmt.Sprintf("multiple metadata are named %q", name))
+		}
+
+		found = &(*metadata)[i]
+	}
+
+	return found, nil
+}
+
+// indent prefixes every line of the text with the given number of spaces, e.g. to embed a file in write_files.
+func indent(spaces int, text string) string {
+	prefix := strings.Repeat(" ", spaces)
+	lines := strings.Split(text, "\n")
+
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,74 @@
+package go_tilaa_test
+
+import (
+	"context"
+	"testing"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+	"github.com/pascal-splotches/go-tilaa/tilaamock"
+)
+
+func TestMetadataTemplateApplyReturnsAddedId(t *testing.T) {
+	services := tilaamock.NewServices()
+	client, err := services.Client()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	services.Metadata.AddFunc = func(ctx context.Context, metadata *go_tilaa.Metadata) (*go_tilaa.Metadata, error) {
+		added := *metadata
+		added.Id = 42
+
+		return &added, nil
+	}
+
+	metadataTemplate, err := go_tilaa.NewMetadataTemplate(client, "{{.Name}}-init", "#cloud-config\nhostname: {{.Hostname}}\n")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metadataId, err := metadataTemplate.Apply(context.Background(), &go_tilaa.VirtualMachine{Name: "web1"}, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if metadataId != 42 {
+		t.Errorf("expected metadata 42, got %d", metadataId)
+	}
+}
+
+func TestMetadataTemplateApplyEditsExisting(t *testing.T) {
+	services := tilaamock.NewServices()
+	client, err := services.Client()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	services.Metadata.ListFunc = func(ctx context.Context) (*[]go_tilaa.Metadata, error) {
+		return &[]go_tilaa.Metadata{{Id: 7, Name: "web1-init", UserData: "#cloud-config\nhostname: old\n"}}, nil
+	}
+
+	metadataTemplate, err := go_tilaa.NewMetadataTemplate(client, "{{.Name}}-init", "#cloud-config\nhostname: {{.Hostname}}\n")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metadataId, err := metadataTemplate.Apply(context.Background(), &go_tilaa.VirtualMachine{Name: "web1"}, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if metadataId != 7 {
+		t.Errorf("expected metadata 7, got %d", metadataId)
+	}
+
+	if calls := services.Metadata.CallsTo("Edit"); len(calls) != 1 || len(services.Metadata.CallsTo("Add")) != 0 {
+		t.Errorf("expected a single edit, got %d", len(calls))
+	}
+}
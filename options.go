@@ -0,0 +1,179 @@
+package go_tilaa
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Option configures a Client created through NewWithOptions. Options are applied in the order they are given.
+type Option func(*Client) error
+
+// WithHttpClient replaces the http.Client used to perform requests, allowing custom transports, proxies and TLS
+// configuration.
+func WithHttpClient(httpClient *http.Client) Option {
+	return func(client *Client) error {
+		if httpClient == nil {
+			return NewClientError("http client can not be nil")
+		}
+
+		client.httpClient = httpClient
+
+		return nil
+	}
+}
+
+// WithBaseUrl points the Client at a different API endpoint, for example a local test server.
+func WithBaseUrl(baseUrl string) Option {
+	return func(client *Client) error {
+		parsedUrl, err := url.Parse(baseUrl)
+
+		if err != nil {
+			return NewClientError(err.Error())
+		}
+
+		if !strings.HasSuffix(parsedUrl.Path, "/") {
+			parsedUrl.Path += "/"
+		}
+
+		client.BaseUrl = parsedUrl
+
+		return nil
+	}
+}
+
+// WithUserAgentSuffix appends the given suffix to the default User-Agent header.
+func WithUserAgentSuffix(suffix string) Option {
+	return func(client *Client) error {
+		if suffix != "" {
+			client.UserAgent = client.UserAgent + " " + suffix
+		}
+
+		return nil
+	}
+}
+
+// WithTimeout sets the timeout of requests. It is applied to a copy of the http.Client once all options have been
+// applied, so the http.Client given to WithHttpClient is left untouched regardless of the order of the options.
+func WithTimeout(timeout time.Duration) Option {
+	return func(client *Client) error {
+		client.timeout = &timeout
+
+		return nil
+	}
+}
+
+// WithApiVersion overrides the API version prefixed to every request path.
+func WithApiVersion(version string) Option {
+	return func(client *Client) error {
+		if version == "" {
+			return NewClientError("api version can not be empty")
+		}
+
+		client.ApiVersion = version
+
+		return nil
+	}
+}
+
+// WithBasicAuth sets the credentials used to authenticate against the API.
+func WithBasicAuth(username string, password string) Option {
+	return func(client *Client) error {
+		client.SetBasicAuth(username, password)
+
+		return nil
+	}
+}
+
+// WithVirtualMachineService replaces the service used for virtual machines, for example with a mock in tests.
+func WithVirtualMachineService(service VirtualMachineServiceInterface) Option {
+	return func(client *Client) error {
+		if service == nil {
+			return NewClientError("virtual machine service can not be nil")
+		}
+
+		client.VirtualMachine = service
+
+		return nil
+	}
+}
+
+// WithSnapshotService replaces the service used for snapshots.
+func WithSnapshotService(service SnapshotServiceInterface) Option {
+	return func(client *Client) error {
+		if service == nil {
+			return NewClientError("snapshot service can not be nil")
+		}
+
+		client.Snapshot = service
+
+		return nil
+	}
+}
+
+// WithPresetService replaces the service used for presets. It should be given before WithCatalogCache, which wraps the
+// configured service.
+func WithPresetService(service PresetServiceInterface) Option {
+	return func(client *Client) error {
+		if service == nil {
+			return NewClientError("preset service can not be nil")
+		}
+
+		client.Preset = service
+
+		return nil
+	}
+}
+
+// WithTemplateService replaces the service used for templates. It should be given before WithCatalogCache.
+func WithTemplateService(service TemplateServiceInterface) Option {
+	return func(client *Client) error {
+		if service == nil {
+			return NewClientError("template service can not be nil")
+		}
+
+		client.Template = service
+
+		return nil
+	}
+}
+
+// WithSiteService replaces the service used for sites. It should be given before WithCatalogCache.
+func WithSiteService(service SiteServiceInterface) Option {
+	return func(client *Client) error {
+		if service == nil {
+			return NewClientError("site service can not be nil")
+		}
+
+		client.Site = service
+
+		return nil
+	}
+}
+
+// WithMetadataService replaces the service used for metadata.
+func WithMetadataService(service MetadataServiceInterface) Option {
+	return func(client *Client) error {
+		if service == nil {
+			return NewClientError("metadata service can not be nil")
+		}
+
+		client.Metadata = service
+
+		return nil
+	}
+}
+
+// WithSshKeyService replaces the service used for SSH keys.
+func WithSshKeyService(service SshKeyServiceInterface) Option {
+	return func(client *Client) error {
+		if service == nil {
+			return NewClientError("ssh key service can not be nil")
+		}
+
+		client.SshKey = service
+
+		return nil
+	}
+}
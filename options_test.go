@@ -0,0 +1,30 @@
+package go_tilaa
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWithTimeoutCopiesHttpClient(t *testing.T) {
+	httpClient := &http.Client{}
+
+	for _, options := range [][]Option{
+		{WithHttpClient(httpClient), WithTimeout(time.Second)},
+		{WithTimeout(time.Second), WithHttpClient(httpClient)},
+	} {
+		client, err := NewWithOptions(options...)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if client.httpClient.Timeout != time.Second {
+			t.Errorf("expected a timeout of 1s, got %s", client.httpClient.Timeout)
+		}
+
+		if client.httpClient == httpClient || httpClient.Timeout != 0 {
+			t.Error("the given http.Client was modified")
+		}
+	}
+}
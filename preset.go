@@ -1,9 +1,15 @@
 package go_tilaa
 
+import (
+	"context"
+	"sort"
+)
+
 const presetsBasePath = "presets"
 
 type PresetServiceInterface interface {
 	List() (*Presets, error)
+	ListWithContext(context.Context) (*Presets, error)
 }
 
 type PresetService struct {
@@ -32,10 +38,13 @@ type PresetsResponse struct {
 }
 
 func (service *PresetService) List() (*Presets, error) {
-	// TODO: Runtime cache presets
+	return service.ListWithContext(context.Background())
+}
+
+func (service *PresetService) ListWithContext(ctx context.Context) (*Presets, error) {
 	var response PresetsResponse
 
-	_, err := service.client.Get(presetsBasePath, &response)
+	_, err := service.client.GetWithContext(ctx, presetsBasePath, &response)
 
 	if err != nil {
 		return nil, err
@@ -52,7 +61,128 @@ func (service *PresetService) List() (*Presets, error) {
 	return &presets, err
 }
 
-// TODO: Add validation helpers to Presets structure
+// SetClient binds the presets to the given client.
+func (presets *Presets) SetClient(client *Client) {
+	presets.client = client
+}
+
+func (presets *Presets) ValidRam(size int) bool {
+	return containsSize(presets.Ram.Sizes, size)
+}
+
+func (presets *Presets) ValidStorage(storageType StorageType, size int) bool {
+	return containsSize(presets.StorageSizes(storageType), size)
+}
+
+func (presets *Presets) ValidStorageType(storageType StorageType) bool {
+	for _, available := range presets.StorageTypes() {
+		if available == storageType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NearestRam returns the available RAM size closest to the given size, rounding up or down. The second return value
+// is false if there is no available size in that direction.
+func (presets *Presets) NearestRam(size int, roundUp bool) (int, bool) {
+	return nearestSize(presets.Ram.Sizes, size, roundUp)
+}
+
+// NearestStorage returns the available storage size of the given type closest to the given size, rounding up or down.
+// The second return value is false if there is no available size in that direction.
+func (presets *Presets) NearestStorage(storageType StorageType, size int, roundUp bool) (int, bool) {
+	return nearestSize(presets.StorageSizes(storageType), size, roundUp)
+}
+
+func (presets *Presets) StorageTypes() []StorageType {
+	storageTypes := make([]StorageType, len(presets.Storage))
+
+	for i, storage := range presets.Storage {
+		storageTypes[i] = StorageType(storage.Type)
+	}
+
+	return storageTypes
+}
+
+// StorageSizes returns the sorted sizes available for the given storage type.
+func (presets *Presets) StorageSizes(storageType StorageType) []int {
+	for _, storage := range presets.Storage {
+		if StorageType(storage.Type) == storageType {
+			return sortedSizes(storage.Sizes)
+		}
+	}
+
+	return nil
+}
+
+// RamSizes returns the sorted available RAM sizes.
+func (presets *Presets) RamSizes() []int {
+	return sortedSizes(presets.Ram.Sizes)
+}
+
+func (presets *Presets) MinRam() int {
+	return firstSize(presets.RamSizes())
+}
+
+func (presets *Presets) MaxRam() int {
+	return lastSize(presets.RamSizes())
+}
+
+func (presets *Presets) MinStorage(storageType StorageType) int {
+	return firstSize(presets.StorageSizes(storageType))
+}
+
+func (presets *Presets) MaxStorage(storageType StorageType) int {
+	return lastSize(presets.StorageSizes(storageType))
+}
+
+func nearestSize(sizes []int, size int, roundUp bool) (int, bool) {
+	sizes = sortedSizes(sizes)
+
+	if roundUp {
+		for _, available := range sizes {
+			if available >= size {
+				return available, true
+			}
+		}
+
+		return 0, false
+	}
+
+	for i := len(sizes) - 1; i >= 0; i-- {
+		if sizes[i] <= size {
+			return sizes[i], true
+		}
+	}
+
+	return 0, false
+}
+
+func sortedSizes(sizes []int) []int {
+	sorted := append([]int{}, sizes...)
+
+	sort.Ints(sorted)
+
+	return sorted
+}
+
+func firstSize(sizes []int) int {
+	if len(sizes) == 0 {
+		return 0
+	}
+
+	return sizes[0]
+}
+
+func lastSize(sizes []int) int {
+	if len(sizes) == 0 {
+		return 0
+	}
+
+	return sizes[len(sizes)-1]
+}
 
 func NewPresets() *Presets {
 	return &Presets{}
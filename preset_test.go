@@ -0,0 +1,149 @@
+package go_tilaa_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+)
+
+// testPresets lists its sizes out of order, as nothing guarantees the API sorts them.
+func testPresets(t *testing.T) *go_tilaa.Presets {
+	var presets go_tilaa.Presets
+
+	err := json.Unmarshal([]byte(`{
+		"ram": {"sizes": [2048, 1024, 4096]},
+		"storage": [
+			{"type": "ssd", "sizes": [40, 10, 20]},
+			{"type": "hdd", "sizes": [100]}
+		]
+	}`), &presets)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &presets
+}
+
+func TestPresetsValidSizes(t *testing.T) {
+	presets := testPresets(t)
+	empty := go_tilaa.NewPresets()
+
+	tests := []struct {
+		name     string
+		valid    bool
+		expected bool
+	}{
+		{"ram 1024", presets.ValidRam(1024), true},
+		{"ram 4096", presets.ValidRam(4096), true},
+		{"ram 3072", presets.ValidRam(3072), false},
+		{"ram 0", presets.ValidRam(0), false},
+		{"ssd 10", presets.ValidStorage(go_tilaa.StorageTypeSsd, 10), true},
+		{"ssd 40", presets.ValidStorage(go_tilaa.StorageTypeSsd, 40), true},
+		{"ssd 100", presets.ValidStorage(go_tilaa.StorageTypeSsd, 100), false},
+		{"hdd 100", presets.ValidStorage(go_tilaa.StorageTypeHdd, 100), true},
+		{"unknown type", presets.ValidStorage("nvme", 10), false},
+		{"ssd type", presets.ValidStorageType(go_tilaa.StorageTypeSsd), true},
+		{"unknown storage type", presets.ValidStorageType("nvme"), false},
+		{"empty ram", empty.ValidRam(1024), false},
+		{"empty storage", empty.ValidStorage(go_tilaa.StorageTypeSsd, 10), false},
+		{"empty storage type", empty.ValidStorageType(go_tilaa.StorageTypeSsd), false},
+	}
+
+	for _, test := range tests {
+		if test.valid != test.expected {
+			t.Errorf("%s: expected %v", test.name, test.expected)
+		}
+	}
+}
+
+func TestPresetsNearest(t *testing.T) {
+	presets := testPresets(t)
+	empty := go_tilaa.NewPresets()
+
+	tests := []struct {
+		name     string
+		presets  *go_tilaa.Presets
+		storage  go_tilaa.StorageType
+		size     int
+		roundUp  bool
+		expected int
+		found    bool
+	}{
+		{"exact up", presets, "", 2048, true, 2048, true},
+		{"exact down", presets, "", 2048, false, 2048, true},
+		{"between up", presets, "", 2049, true, 4096, true},
+		{"between down", presets, "", 4095, false, 2048, true},
+		{"below smallest up", presets, "", 1, true, 1024, true},
+		{"below smallest down", presets, "", 1023, false, 0, false},
+		{"above largest up", presets, "", 4097, true, 0, false},
+		{"above largest down", presets, "", 8192, false, 4096, true},
+		{"empty up", empty, "", 1024, true, 0, false},
+		{"empty down", empty, "", 1024, false, 0, false},
+		{"ssd between up", presets, go_tilaa.StorageTypeSsd, 11, true, 20, true},
+		{"ssd between down", presets, go_tilaa.StorageTypeSsd, 39, false, 20, true},
+		{"ssd above largest up", presets, go_tilaa.StorageTypeSsd, 41, true, 0, false},
+		{"hdd below smallest down", presets, go_tilaa.StorageTypeHdd, 99, false, 0, false},
+		{"unknown type", presets, "nvme", 10, true, 0, false},
+		{"empty storage", empty, go_tilaa.StorageTypeSsd, 10, true, 0, false},
+	}
+
+	for _, test := range tests {
+		var size int
+		var found bool
+
+		if test.storage == "" {
+			size, found = test.presets.NearestRam(test.size, test.roundUp)
+		} else {
+			size, found = test.presets.NearestStorage(test.storage, test.size, test.roundUp)
+		}
+
+		if size != test.expected || found != test.found {
+			t.Errorf("%s: expected %d and %v, got %d and %v", test.name, test.expected, test.found, size, found)
+		}
+	}
+}
+
+func TestPresetsSizes(t *testing.T) {
+	presets := testPresets(t)
+	empty := go_tilaa.NewPresets()
+
+	if sizes := presets.RamSizes(); !reflect.DeepEqual(sizes, []int{1024, 2048, 4096}) {
+		t.Errorf("expected sorted RAM sizes, got %v", sizes)
+	}
+
+	if sizes := presets.StorageSizes(go_tilaa.StorageTypeSsd); !reflect.DeepEqual(sizes, []int{10, 20, 40}) {
+		t.Errorf("expected sorted SSD sizes, got %v", sizes)
+	}
+
+	if presets.Storage[0].Sizes[0] != 40 {
+		t.Error("sorting modified the presets")
+	}
+
+	tests := []struct {
+		name     string
+		size     int
+		expected int
+	}{
+		{"min ram", presets.MinRam(), 1024},
+		{"max ram", presets.MaxRam(), 4096},
+		{"min ssd", presets.MinStorage(go_tilaa.StorageTypeSsd), 10},
+		{"max ssd", presets.MaxStorage(go_tilaa.StorageTypeSsd), 40},
+		{"max unknown type", presets.MaxStorage("nvme"), 0},
+		{"empty min ram", empty.MinRam(), 0},
+		{"empty max ram", empty.MaxRam(), 0},
+		{"empty min storage", empty.MinStorage(go_tilaa.StorageTypeSsd), 0},
+	}
+
+	for _, test := range tests {
+		if test.size != test.expected {
+			t.Errorf("%s: expected %d, got %d", test.name, test.expected, test.size)
+		}
+	}
+
+	if types := presets.StorageTypes(); !reflect.DeepEqual(types, []go_tilaa.StorageType{go_tilaa.StorageTypeSsd, go_tilaa.StorageTypeHdd}) {
+		t.Errorf("unexpected storage types %v", types)
+	}
+}
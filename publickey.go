@@ -0,0 +1,299 @@
+package go_tilaa
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+const (
+	PublicKeyTypeRsa                 = "ssh-rsa"
+	PublicKeyTypeDsa                 = "ssh-dss"
+	PublicKeyTypeEd25519             = "ssh-ed25519"
+	PublicKeyTypeEcdsaP256           = "ecdsa-sha2-nistp256"
+	PublicKeyTypeEcdsaP384           = "ecdsa-sha2-nistp384"
+	PublicKeyTypeEcdsaP521           = "ecdsa-sha2-nistp521"
+	PublicKeyTypeSecurityKeyEd25519  = "sk-ssh-ed25519@openssh.com"
+	PublicKeyTypeSecurityKeyEcdsa256 = "sk-ecdsa-sha2-nistp256@openssh.com"
+
+	// MinRsaBits is the smallest RSA modulus accepted, smaller keys can be factored in reasonable time.
+	MinRsaBits = 2048
+
+	ed25519KeySize = 32
+)
+
+// ecdsaCurves maps the ECDSA key types to the name of their curve and its size in bits.
+var ecdsaCurves = map[string]struct {
+	name string
+	bits int
+}{
+	PublicKeyTypeEcdsaP256:           {"nistp256", 256},
+	PublicKeyTypeEcdsaP384:           {"nistp384", 384},
+	PublicKeyTypeEcdsaP521:           {"nistp521", 521},
+	PublicKeyTypeSecurityKeyEcdsa256: {"nistp256", 256},
+}
+
+// PublicKey is a parsed OpenSSH public key in the authorized_keys format: "<type> <base64 data> [comment]".
+type PublicKey struct {
+	keyType string
+	data    []byte
+	comment string
+	bits    int
+}
+
+// ParsePublicKey parses and checks an OpenSSH public key. DSA keys and RSA keys shorter than MinRsaBits are rejected.
+// Whitespace around and within the key is ignored.
+func ParsePublicKey(text string) (*PublicKey, error) {
+	key, err := parsePublicKey(text)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := key.checkStrength(); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// parsePublicKey parses an OpenSSH public key without rejecting weak keys, e.g. to describe a key which was stored
+// before.
+func parsePublicKey(text string) (*PublicKey, error) {
+	fields := strings.Fields(text)
+
+	if len(fields) < 2 {
+		return nil, NewInvalidPublicKeyError("expected a key type followed by the base64 encoded key")
+	}
+
+	key := &PublicKey{keyType: fields[0], comment: strings.Join(fields[2:], " ")}
+
+	data, err := base64.StdEncoding.DecodeString(fields[1])
+
+	if err != nil {
+		return nil, NewInvalidPublicKeyError("key data is not valid base64")
+	}
+
+	key.data = data
+
+	if err := key.parseData(); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// ParseAuthorizedKey parses a line of an authorized_keys file. Options preceding the key, such as no-pty or
+// command="...", are skipped.
+func ParseAuthorizedKey(line string) (*PublicKey, error) {
+	line = strings.TrimSpace(line)
+
+	if fields := strings.Fields(line); len(fields) > 0 && !isPublicKeyType(fields[0]) {
+		line = skipOptions(line)
+	}
+
+	return ParsePublicKey(line)
+}
+
+// skipOptions removes the comma separated options from the start of an authorized_keys line. Options end at the first
+// whitespace outside of double quotes.
+func skipOptions(line string) string {
+	quoted := false
+
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && quoted:
+			i++
+		case line[i] == '"':
+			quoted = !quoted
+		case (line[i] == ' ' || line[i] == '\t') && !quoted:
+			return line[i:]
+		}
+	}
+
+	return ""
+}
+
+func isPublicKeyType(keyType string) bool {
+	switch keyType {
+	case PublicKeyTypeRsa, PublicKeyTypeDsa, PublicKeyTypeEd25519, PublicKeyTypeSecurityKeyEd25519:
+		return true
+	}
+
+	_, ok := ecdsaCurves[keyType]
+
+	return ok
+}
+
+// parseData checks the wire format of the key data, which starts with the key type followed by fields specific to
+// the type, and determines the size of the key.
+func (key *PublicKey) parseData() error {
+	reader := &wireReader{data: key.data}
+
+	if embeddedType, ok := reader.string(); !ok || string(embeddedType) != key.keyType {
+		return NewInvalidPublicKeyError("key data does not match key type " + key.keyType)
+	}
+
+	switch key.keyType {
+	case PublicKeyTypeRsa:
+		exponent, ok := reader.string()
+		modulus, ok2 := reader.string()
+
+		if !ok || !ok2 || len(exponent) == 0 {
+			return NewInvalidPublicKeyError("truncated RSA key")
+		}
+
+		key.bits = new(big.Int).SetBytes(modulus).BitLen()
+	case PublicKeyTypeDsa:
+		prime, ok := reader.string()
+
+		for i := 0; i < 3 && ok; i++ {
+			_, ok = reader.string()
+		}
+
+		if !ok || len(prime) == 0 {
+			return NewInvalidPublicKeyError("truncated DSA key")
+		}
+
+		key.bits = new(big.Int).SetBytes(prime).BitLen()
+	case PublicKeyTypeEd25519, PublicKeyTypeSecurityKeyEd25519:
+		publicKey, ok := reader.string()
+
+		if !ok || len(publicKey) != ed25519KeySize {
+			return NewInvalidPublicKeyError("invalid Ed25519 key")
+		}
+
+		key.bits = 256
+	case PublicKeyTypeEcdsaP256, PublicKeyTypeEcdsaP384, PublicKeyTypeEcdsaP521, PublicKeyTypeSecurityKeyEcdsa256:
+		curve := ecdsaCurves[key.keyType]
+		name, ok := reader.string()
+		point, ok2 := reader.string()
+
+		if !ok || !ok2 || string(name) != curve.name {
+			return NewInvalidPublicKeyError("invalid ECDSA key")
+		}
+
+		// Only uncompressed points are used by OpenSSH: 0x04 followed by both coordinates
+		coordinateSize := (curve.bits + 7) / 8
+
+		if len(point) != 1+2*coordinateSize || point[0] != 4 {
+			return NewInvalidPublicKeyError("invalid ECDSA curve point")
+		}
+
+		key.bits = curve.bits
+	default:
+		return NewInvalidPublicKeyError("unsupported key type " + key.keyType)
+	}
+
+	// Security keys end with the application the key was registered for
+	if strings.HasPrefix(key.keyType, "sk-") {
+		if _, ok := reader.string(); !ok {
+			return NewInvalidPublicKeyError("security key is missing its application")
+		}
+	}
+
+	if reader.remaining() > 0 {
+		return NewInvalidPublicKeyError("unexpected data after key")
+	}
+
+	return nil
+}
+
+// publicKeyErrorReason returns the reason of an InvalidPublicKeyError, without its prefix, or the message of any other
+// error.
+func publicKeyErrorReason(err error) string {
+	var invalidPublicKeyError *InvalidPublicKeyError
+
+	if errors.As(err, &invalidPublicKeyError) {
+		return invalidPublicKeyError.Reason()
+	}
+
+	return err.Error()
+}
+
+// checkStrength rejects DSA keys and RSA keys shorter than MinRsaBits.
+func (key *PublicKey) checkStrength() error {
+	switch {
+	case key.keyType == PublicKeyTypeDsa:
+		return NewInvalidPublicKeyError("DSA keys are not supported")
+	case key.keyType == PublicKeyTypeRsa && key.bits < MinRsaBits:
+		return NewInvalidPublicKeyError("RSA keys must be at least 2048 bits")
+	}
+
+	return nil
+}
+
+func (key *PublicKey) Type() string {
+	return key.keyType
+}
+
+// Bits returns the size of the key: the modulus of RSA keys, the curve of ECDSA keys and 256 for Ed25519 keys.
+func (key *PublicKey) Bits() int {
+	return key.bits
+}
+
+func (key *PublicKey) Comment() string {
+	return key.comment
+}
+
+// FingerprintSHA256 returns the fingerprint as shown by ssh-keygen -l, e.g. "SHA256:nThbg6kXUpJWGl7E1IGOCspRomTxdCARLviKw6E5SY8".
+func (key *PublicKey) FingerprintSHA256() string {
+	sum := sha256.Sum256(key.data)
+
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// FingerprintMD5 returns the legacy fingerprint as shown by ssh-keygen -l -E md5, e.g. "MD5:16:27:ac:a5:...".
+func (key *PublicKey) FingerprintMD5() string {
+	sum := md5.Sum(key.data)
+	encoded := hex.EncodeToString(sum[:])
+	pairs := make([]string, 0, len(sum))
+
+	for i := 0; i < len(encoded); i += 2 {
+		pairs = append(pairs, encoded[i:i+2])
+	}
+
+	return "MD5:" + strings.Join(pairs, ":")
+}
+
+// String returns the normalised key: its type, data and comment separated by single spaces.
+func (key *PublicKey) String() string {
+	text := key.keyType + " " + base64.StdEncoding.EncodeToString(key.data)
+
+	if key.comment != "" {
+		text += " " + key.comment
+	}
+
+	return text
+}
+
+// wireReader reads length prefixed strings of the SSH wire format.
+type wireReader struct {
+	data []byte
+}
+
+func (reader *wireReader) string() ([]byte, bool) {
+	if len(reader.data) < 4 {
+		return nil, false
+	}
+
+	length := binary.BigEndian.Uint32(reader.data)
+
+	if uint64(length) > uint64(len(reader.data)-4) {
+		return nil, false
+	}
+
+	value := reader.data[4 : 4+length]
+	reader.data = reader.data[4+length:]
+
+	return value, true
+}
+
+func (reader *wireReader) remaining() int {
+	return len(reader.data)
+}
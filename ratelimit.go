@@ -0,0 +1,173 @@
+package go_tilaa
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket limiting the rate at which requests are sent. Tokens are replenished at a fixed rate
+// up to the burst size; every request consumes a single token. It is safe for concurrent use.
+type RateLimiter struct {
+	mutex  sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func NewRateLimiter(requestsPerSecond float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &RateLimiter{
+		rate:   requestsPerSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a token is available or the context is done.
+func (limiter *RateLimiter) Wait(ctx context.Context) error {
+	delay := limiter.reserve()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	if err := sleepWithContext(ctx, delay); err != nil {
+		limiter.cancel()
+
+		return err
+	}
+
+	return nil
+}
+
+func (limiter *RateLimiter) reserve() time.Duration {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	if limiter.rate <= 0 || math.IsInf(limiter.rate, 1) {
+		return 0
+	}
+
+	now := time.Now()
+
+	limiter.tokens = math.Min(limiter.burst, limiter.tokens+now.Sub(limiter.last).Seconds()*limiter.rate)
+	limiter.last = now
+	limiter.tokens--
+
+	if limiter.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-limiter.tokens / limiter.rate * float64(time.Second))
+}
+
+func (limiter *RateLimiter) cancel() {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	limiter.tokens = math.Min(limiter.burst, limiter.tokens+1)
+}
+
+// WithRateLimit limits the Client, and all of its services, to the given number of requests per second with the given
+// burst size.
+func WithRateLimit(requestsPerSecond float64, burst int) Option {
+	return func(client *Client) error {
+		client.rateLimiter = NewRateLimiter(requestsPerSecond, burst)
+
+		return nil
+	}
+}
+
+// WithRateLimiter uses the given RateLimiter, allowing a single limit to be shared by multiple clients.
+func WithRateLimiter(limiter *RateLimiter) Option {
+	return func(client *Client) error {
+		client.rateLimiter = limiter
+
+		return nil
+	}
+}
+
+// WithMaxInFlight caps the number of requests the Client has in flight at any time. A value of 0 removes the cap.
+func WithMaxInFlight(requests int) Option {
+	return func(client *Client) error {
+		if requests < 0 {
+			return NewClientError("max in flight requests can not be negative")
+		}
+
+		client.inFlight = nil
+
+		if requests > 0 {
+			client.inFlight = make(chan struct{}, requests)
+		}
+
+		return nil
+	}
+}
+
+// throttle waits for the rate limiter and a free in flight slot. The returned function releases the slot.
+func (client *Client) throttle(ctx context.Context) (func(), error) {
+	if client.rateLimiter != nil {
+		if err := client.rateLimiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if client.inFlight == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case client.inFlight <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() { <-client.inFlight })
+	}, nil
+}
+
+// releasingBody releases the in flight slot of a request once its response body has been closed.
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (body *releasingBody) Close() error {
+	err := body.ReadCloser.Close()
+
+	body.release()
+
+	return err
+}
+
+func (client *Client) roundTrip(request *http.Request) (*http.Response, error) {
+	release, err := client.throttle(request.Context())
+
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := client.httpClient.Do(request)
+
+	if err != nil {
+		release()
+
+		return nil, err
+	}
+
+	response.Body = &releasingBody{ReadCloser: response.Body, release: release}
+
+	return response, nil
+}
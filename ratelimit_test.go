@@ -0,0 +1,204 @@
+package go_tilaa
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// transportFunc answers requests without a server.
+type transportFunc func(request *http.Request) (*http.Response, error)
+
+func (transport transportFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return transport(request)
+}
+
+func okResponse(request *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader("{}")), Request: request}, nil
+}
+
+func newThrottledClient(t *testing.T, transport transportFunc, options ...Option) *Client {
+	client, err := NewWithOptions(append([]Option{WithHttpClient(&http.Client{Transport: transport})}, options...)...)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+func newTestRequest(t *testing.T, ctx context.Context) *http.Request {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://tilaa.test/v1/sites", nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return request
+}
+
+func TestRateLimiterPacing(t *testing.T) {
+	limiter := NewRateLimiter(50, 2)
+	ctx := context.Background()
+	started := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if err := limiter.Wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if elapsed := time.Since(started); elapsed > 10*time.Millisecond {
+		t.Errorf("expected the burst to pass immediately, took %s", elapsed)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if elapsed := time.Since(started); elapsed < 50*time.Millisecond || elapsed > time.Second {
+		t.Errorf("expected 3 requests past the burst to take about 60ms at 50 per second, took %s", elapsed)
+	}
+}
+
+func TestRateLimiterWithoutRate(t *testing.T) {
+	limiter := NewRateLimiter(0, 1)
+
+	for i := 0; i < 100; i++ {
+		if delay := limiter.reserve(); delay != 0 {
+			t.Fatalf("expected no delay without a rate, got %s", delay)
+		}
+	}
+}
+
+func TestRateLimiterWaitCancelled(t *testing.T) {
+	limiter := NewRateLimiter(1, 1)
+
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := limiter.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the wait to be cancelled, got %v", err)
+	}
+
+	// The token reserved by the cancelled wait is returned, so the next request waits for a single token
+	if delay := limiter.reserve(); delay > time.Second {
+		t.Errorf("expected the cancelled token to be returned, next request waits %s", delay)
+	}
+}
+
+func TestMaxInFlightCapsConcurrentRequests(t *testing.T) {
+	var mutex sync.Mutex
+	var current, peak int
+
+	client := newThrottledClient(t, func(request *http.Request) (*http.Response, error) {
+		mutex.Lock()
+		current++
+
+		if current > peak {
+			peak = current
+		}
+
+		mutex.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mutex.Lock()
+		current--
+		mutex.Unlock()
+
+		return okResponse(request)
+	}, WithMaxInFlight(2))
+
+	var group sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		group.Add(1)
+
+		go func() {
+			defer group.Done()
+
+			if _, err := client.Site.List(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	group.Wait()
+
+	if peak != 2 {
+		t.Errorf("expected at most 2 requests in flight, got %d", peak)
+	}
+
+	if len(client.inFlight) != 0 {
+		t.Errorf("expected every slot to be released, %d are taken", len(client.inFlight))
+	}
+}
+
+func TestMaxInFlightReleasesSlotOnClose(t *testing.T) {
+	client := newThrottledClient(t, okResponse, WithMaxInFlight(1))
+
+	response, err := client.roundTrip(newTestRequest(t, context.Background()))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := client.roundTrip(newTestRequest(t, ctx)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the request to wait for the open response until cancelled, got %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := response.Body.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(client.inFlight) != 0 {
+		t.Fatalf("expected closing the body to release its slot once, %d are taken", len(client.inFlight))
+	}
+
+	response, err = client.roundTrip(newTestRequest(t, context.Background()))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = response.Body.Close()
+}
+
+func TestMaxInFlightReleasesSlotOnError(t *testing.T) {
+	client := newThrottledClient(t, func(request *http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	}, WithMaxInFlight(1))
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.roundTrip(newTestRequest(t, context.Background())); err == nil {
+			t.Fatal("expected the request to fail")
+		}
+
+		if len(client.inFlight) != 0 {
+			t.Fatalf("expected the failed request to release its slot, %d are taken", len(client.inFlight))
+		}
+	}
+}
+
+func TestWithMaxInFlightRejectsNegative(t *testing.T) {
+	if _, err := NewWithOptions(WithMaxInFlight(-1)); err == nil {
+		t.Error("expected a negative cap to be rejected")
+	}
+}
@@ -0,0 +1,71 @@
+package reconcile
+
+import (
+	"encoding/json"
+	"os"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+)
+
+// Manifest describes the desired state of the virtual machines of an account.
+type Manifest struct {
+	Machines []MachineSpec `json:"machines"`
+
+	// Prune cancels machines which exist but are not listed in the manifest.
+	Prune bool `json:"prune,omitempty"`
+}
+
+// MachineSpec describes the desired state of a single virtual machine. Machines are matched by Id when it is set,
+// allowing them to be renamed, and by Name otherwise.
+type MachineSpec struct {
+	Id          int                  `json:"id,omitempty"`
+	Name        string               `json:"name"`
+	DnsName     string               `json:"dns_name,omitempty"`
+	Template    string               `json:"template"`
+	Site        string               `json:"site"`
+	Ram         int                  `json:"ram"`
+	Storage     int                  `json:"storage"`
+	StorageType go_tilaa.StorageType `json:"storage_type,omitempty"`
+	CpuCores    int                  `json:"cpu_cores"`
+	CpuCap      int                  `json:"cpu_cap,omitempty"`
+	SshKeys     []int                `json:"ssh_keys,omitempty"`
+	Metadata    int                  `json:"metadata,omitempty"`
+}
+
+// LoadManifest reads a JSON encoded Manifest from the given file.
+func LoadManifest(path string) (*Manifest, error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	var manifest Manifest
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&manifest); err != nil {
+		return nil, go_tilaa.NewClientError("invalid manifest " + path + ": " + err.Error())
+	}
+
+	return &manifest, nil
+}
+
+func (spec *MachineSpec) storageType() go_tilaa.StorageType {
+	if spec.StorageType == "" {
+		return go_tilaa.StorageTypeSsd
+	}
+
+	return spec.StorageType
+}
+
+func (spec *MachineSpec) cpuCap() int {
+	if spec.CpuCap == 0 {
+		return spec.CpuCores * go_tilaa.MaxCpuCapPerCore
+	}
+
+	return spec.CpuCap
+}
@@ -0,0 +1,123 @@
+package reconcile
+
+import (
+	"fmt"
+	"strings"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+)
+
+type Action string
+
+const (
+	ActionCreate    Action = "create"
+	ActionEdit      Action = "edit"
+	ActionReinstall Action = "reinstall"
+	ActionCancel    Action = "cancel"
+	ActionUncancel  Action = "uncancel"
+	ActionDelete    Action = "delete"
+)
+
+// Change describes a single field which differs between the current and the desired state.
+type Change struct {
+	Field string
+	From  string
+	To    string
+}
+
+// Step is a single operation of a Plan. Machine is nil for ActionCreate, Spec is nil for ActionCancel. Steps syncing
+// SSH keys only set SshKey, which is the key to add for ActionCreate.
+type Step struct {
+	Action      Action
+	Name        string
+	Machine     *go_tilaa.VirtualMachine
+	Spec        *MachineSpec
+	Template    *go_tilaa.Template
+	SshKey      *go_tilaa.SshKey
+	Changes     []Change
+	Destructive bool
+}
+
+// Plan lists the steps needed to bring an account in line with a Manifest or SshKeyManifest. Warnings describe
+// differences which can not be reconciled, such as a machine living in another site.
+type Plan struct {
+	Steps    []Step
+	Warnings []string
+}
+
+func (change Change) String() string {
+	if change.From == "" {
+		return fmt.Sprintf("%s = %s", change.Field, change.To)
+	}
+
+	return fmt.Sprintf("%s: %s -> %s", change.Field, change.From, change.To)
+}
+
+func (step Step) String() string {
+	marker := map[Action]string{
+		ActionCreate:    "+",
+		ActionEdit:      "~",
+		ActionReinstall: "!",
+		ActionCancel:    "-",
+		ActionUncancel:  "+",
+		ActionDelete:    "-",
+	}[step.Action]
+
+	line := fmt.Sprintf("%s %s %s", marker, step.Action, step.Name)
+
+	if step.Destructive {
+		line += " (destructive)"
+	}
+
+	for _, change := range step.Changes {
+		line += "\n    " + change.String()
+	}
+
+	return line
+}
+
+// String renders the plan for a dry run.
+func (plan *Plan) String() string {
+	if plan.Empty() && len(plan.Warnings) == 0 {
+		return "No changes, the account matches the manifest.\n"
+	}
+
+	var builder strings.Builder
+
+	for _, step := range plan.Steps {
+		builder.WriteString(step.String())
+		builder.WriteString("\n")
+	}
+
+	for _, warning := range plan.Warnings {
+		builder.WriteString("warning: ")
+		builder.WriteString(warning)
+		builder.WriteString("\n")
+	}
+
+	return builder.String()
+}
+
+func (plan *Plan) Empty() bool {
+	return len(plan.Steps) == 0
+}
+
+func (plan *Plan) DestructiveSteps() []Step {
+	var steps []Step
+
+	for _, step := range plan.Steps {
+		if step.Destructive {
+			steps = append(steps, step)
+		}
+	}
+
+	return steps
+}
+
+func (plan *Plan) add(step Step) {
+	plan.Steps = append(plan.Steps, step)
+}
+
+func (plan *Plan) warn(format string, args ...interface{}) {
+	plan.Warnings = append(plan.Warnings, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,452 @@
+// Package reconcile brings the virtual machines of a Tilaa account in line with a declarative Manifest. A Reconciler
+// first computes a Plan of creates, edits, reinstalls and cancellations, which can be printed for a dry run, and then
+// applies it. Destructive steps are refused unless explicitly allowed.
+//
+// The SSH keys of an account are synced the same way from an SshKeyManifest, read from an authorized_keys file or a
+// directory of public keys.
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+)
+
+type StepStatus string
+
+const (
+	StepStarted   StepStatus = "started"
+	StepSucceeded StepStatus = "succeeded"
+	StepFailed    StepStatus = "failed"
+)
+
+// ProgressFunc is called before and after every step is applied. The error is only set for StepFailed.
+type ProgressFunc func(step Step, status StepStatus, err error)
+
+type Reconciler struct {
+	Client *go_tilaa.Client
+
+	// AllowDestructive permits reinstalls, cancellations, storage shrinks and SSH key deletions, which destroy data.
+	AllowDestructive bool
+
+	// ContinueOnError applies the remaining steps after a step failed instead of stopping.
+	ContinueOnError bool
+
+	Progress ProgressFunc
+}
+
+// DestructiveStepsError is returned by Apply when the plan contains destructive steps which were not allowed.
+type DestructiveStepsError struct {
+	Steps []Step
+}
+
+// ApplyError lists the steps which failed while applying a plan, in the order they were applied.
+type ApplyError struct {
+	Failures []StepFailure
+}
+
+// StepFailure is a step which failed while applying a plan and the error it failed with.
+type StepFailure struct {
+	Step Step
+	Err  error
+}
+
+func NewReconciler(client *go_tilaa.Client) *Reconciler {
+	return &Reconciler{Client: client}
+}
+
+func (error *DestructiveStepsError) Error() string {
+	names := make([]string, len(error.Steps))
+
+	for i, step := range error.Steps {
+		names[i] = fmt.Sprintf("%s %s", step.Action, step.Name)
+	}
+
+	return fmt.Sprintf("Refusing destructive steps: %s", strings.Join(names, ", "))
+}
+
+func (error *ApplyError) Error() string {
+	failures := make([]string, len(error.Failures))
+
+	for i, failure := range error.Failures {
+		failures[i] = fmt.Sprintf("%s %s (%s)", failure.Step.Action, failure.Step.Name, failure.Err.Error())
+	}
+
+	return fmt.Sprintf("Apply Error: %s", strings.Join(failures, "; "))
+}
+
+// Plan compares the manifest with the machines of the account and returns the steps needed to reconcile them.
+func (reconciler *Reconciler) Plan(ctx context.Context, manifest *Manifest) (*Plan, error) {
+	machines, err := reconciler.Client.VirtualMachine.ListWithContext(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	templates, err := reconciler.Client.Template.ListWithContext(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{}
+	matched := map[int]bool{}
+
+	for i := range manifest.Machines {
+		spec := &manifest.Machines[i]
+
+		machine, err := findMachine(*machines, spec)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if machine == nil {
+			plan.add(Step{Action: ActionCreate, Name: spec.Name, Spec: spec, Changes: creationChanges(spec)})
+
+			continue
+		}
+
+		matched[machine.Id] = true
+
+		if err := planMachine(plan, machine, spec, *templates); err != nil {
+			return nil, err
+		}
+	}
+
+	if manifest.Prune {
+		for i := range *machines {
+			machine := &(*machines)[i]
+
+			if matched[machine.Id] || isCancelled(machine) || machine.Status == go_tilaa.VirtualMachineStatusDestroyed {
+				continue
+			}
+
+			plan.add(Step{Action: ActionCancel, Name: machine.Name, Machine: machine, Destructive: true})
+		}
+	}
+
+	return plan, nil
+}
+
+func planMachine(plan *Plan, machine *go_tilaa.VirtualMachine, spec *MachineSpec, templates []go_tilaa.Template) error {
+	if isCancelled(machine) {
+		plan.add(Step{Action: ActionUncancel, Name: machine.Name, Machine: machine, Spec: spec})
+	}
+
+	if spec.Site != "" && !strings.EqualFold(machine.Site.Name, spec.Site) {
+		plan.warn("%s lives in site %s instead of %s, machines can not be moved between sites", machine.Name, machine.Site.Name, spec.Site)
+	}
+
+	if spec.storageType() != machine.Storage.Type {
+		plan.warn("%s uses %s storage instead of %s, the storage type can not be changed", machine.Name, machine.Storage.Type, spec.storageType())
+	}
+
+	if spec.DnsName != "" && machine.DnsName() != spec.DnsName {
+		plan.warn("%s has DNS name %s instead of %s, the DNS name can not be changed", machine.Name, machine.DnsName(), spec.DnsName)
+	}
+
+	if spec.Template != "" && !strings.EqualFold(machine.Template.Name, spec.Template) {
+		template := findTemplate(templates, spec.Template)
+
+		if template == nil {
+			return go_tilaa.NewClientError(fmt.Sprintf("%s: no template named %q", spec.Name, spec.Template))
+		}
+
+		plan.add(Step{
+			Action:      ActionReinstall,
+			Name:        machine.Name,
+			Machine:     machine,
+			Spec:        spec,
+			Template:    template,
+			Changes:     []Change{{Field: "template", From: machine.Template.Name, To: template.Name}},
+			Destructive: true,
+		})
+	} else if (spec.SshKeys != nil && !sameIds(machine.SshKeys.Ints(), spec.SshKeys)) || (spec.Metadata != 0 && int(machine.Metadata) != spec.Metadata) {
+		plan.warn("%s: SSH keys and metadata are only provisioned when a machine is created or reinstalled", machine.Name)
+	}
+
+	var changes []Change
+
+	if spec.Name != "" && machine.Name != spec.Name {
+		changes = append(changes, Change{Field: "name", From: machine.Name, To: spec.Name})
+	}
+
+	if spec.Ram != 0 && machine.Ram != spec.Ram {
+		changes = append(changes, intChange("ram", machine.Ram, spec.Ram))
+	}
+
+	if spec.Storage != 0 && machine.Storage.Size != spec.Storage {
+		changes = append(changes, intChange("storage", machine.Storage.Size, spec.Storage))
+	}
+
+	if spec.CpuCores != 0 && machine.Cpu.Cores != spec.CpuCores {
+		changes = append(changes, intChange("cpu_cores", machine.Cpu.Cores, spec.CpuCores))
+	}
+
+	if spec.CpuCores != 0 && machine.Cpu.Cap != spec.cpuCap() {
+		changes = append(changes, intChange("cpu_cap", machine.Cpu.Cap, spec.cpuCap()))
+	}
+
+	if len(changes) > 0 {
+		plan.add(Step{
+			Action:      ActionEdit,
+			Name:        machine.Name,
+			Machine:     machine,
+			Spec:        spec,
+			Changes:     changes,
+			Destructive: spec.Storage != 0 && spec.Storage < machine.Storage.Size,
+		})
+	}
+
+	return nil
+}
+
+// Apply executes the steps of the plan in order. Destructive steps are refused up front, without applying anything,
+// unless AllowDestructive is set.
+func (reconciler *Reconciler) Apply(ctx context.Context, plan *Plan) error {
+	if destructive := plan.DestructiveSteps(); len(destructive) > 0 && !reconciler.AllowDestructive {
+		return &DestructiveStepsError{Steps: destructive}
+	}
+
+	var failures []StepFailure
+
+	for _, step := range plan.Steps {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		reconciler.progress(step, StepStarted, nil)
+
+		err := reconciler.apply(ctx, step)
+
+		if err != nil {
+			reconciler.progress(step, StepFailed, err)
+
+			failures = append(failures, StepFailure{Step: step, Err: err})
+
+			if !reconciler.ContinueOnError {
+				break
+			}
+
+			continue
+		}
+
+		reconciler.progress(step, StepSucceeded, nil)
+	}
+
+	if len(failures) > 0 {
+		return &ApplyError{Failures: failures}
+	}
+
+	return nil
+}
+
+func (reconciler *Reconciler) apply(ctx context.Context, step Step) error {
+	if step.SshKey != nil {
+		return reconciler.applySshKey(ctx, step)
+	}
+
+	switch step.Action {
+	case ActionCreate:
+		return reconciler.create(ctx, step.Spec)
+	case ActionEdit:
+		return reconciler.edit(ctx, step.Machine, step.Changes)
+	case ActionReinstall:
+		step.Machine.Template = *step.Template
+
+		_, err := reconciler.Client.VirtualMachine.ReinstallWithContext(ctx, step.Machine)
+
+		return err
+	case ActionCancel:
+		return reconciler.cancel(ctx, step.Machine)
+	case ActionUncancel:
+		return reconciler.Client.VirtualMachine.UndoCancellationWithContext(ctx, step.Machine)
+	}
+
+	return go_tilaa.NewClientError("unknown reconcile action " + string(step.Action))
+}
+
+func (reconciler *Reconciler) create(ctx context.Context, spec *MachineSpec) error {
+	builder := go_tilaa.NewVirtualMachineSpec(reconciler.Client).
+		WithName(spec.Name).
+		WithDnsName(spec.DnsName).
+		WithTemplateName(spec.Template).
+		WithSite(spec.Site).
+		WithRam(spec.Ram).
+		WithSshKeys(spec.SshKeys...).
+		WithMetadata(spec.Metadata)
+
+	if spec.storageType() == go_tilaa.StorageTypeHdd {
+		builder.WithHdd(spec.Storage)
+	} else {
+		builder.WithSsd(spec.Storage)
+	}
+
+	if spec.CpuCores != 0 {
+		builder.WithCpu(spec.CpuCores).WithCpuCap(spec.cpuCap())
+	}
+
+	_, err := builder.Create(ctx)
+
+	return err
+}
+
+func (reconciler *Reconciler) edit(ctx context.Context, machine *go_tilaa.VirtualMachine, changes []Change) error {
+	for _, change := range changes {
+		var err error
+
+		switch change.Field {
+		case "name":
+			err = machine.SetName(change.To)
+		case "ram":
+			err = machine.SetRam(atoi(change.To))
+		case "storage":
+			err = machine.SetStorage(atoi(change.To))
+		case "cpu_cores":
+			err = machine.SetCpuCores(atoi(change.To))
+		case "cpu_cap":
+			err = machine.SetCpuCap(atoi(change.To))
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := reconciler.Client.VirtualMachine.EditWithContext(ctx, machine)
+
+	return err
+}
+
+// cancel cancels the machine at the earliest date the API allows.
+func (reconciler *Reconciler) cancel(ctx context.Context, machine *go_tilaa.VirtualMachine) error {
+	dates, err := reconciler.Client.VirtualMachine.GetCancelDatesWithContext(ctx, machine)
+
+	if err != nil {
+		return err
+	}
+
+	if len(*dates) == 0 {
+		return go_tilaa.NewClientError("no cancel dates available for " + machine.Name)
+	}
+
+	date := (*dates)[0]
+
+	for _, candidate := range *dates {
+		if candidate.Before(date) {
+			date = candidate
+		}
+	}
+
+	_, err = reconciler.Client.VirtualMachine.CancelWithContext(ctx, machine, &date)
+
+	return err
+}
+
+func (reconciler *Reconciler) progress(step Step, status StepStatus, err error) {
+	if reconciler.Progress != nil {
+		reconciler.Progress(step, status, err)
+	}
+}
+
+func findMachine(machines []go_tilaa.VirtualMachine, spec *MachineSpec) (*go_tilaa.VirtualMachine, error) {
+	var found *go_tilaa.VirtualMachine
+
+	for i := range machines {
+		machine := &machines[i]
+
+		if machine.Status == go_tilaa.VirtualMachineStatusDestroyed {
+			continue
+		}
+
+		if spec.Id != 0 {
+			if machine.Id == spec.Id {
+				return machine, nil
+			}
+
+			continue
+		}
+
+		if machine.Name != spec.Name {
+			continue
+		}
+
+		if found != nil {
+			return nil, go_tilaa.NewClientError(fmt.Sprintf("multiple machines are named %q, set an id in the manifest", spec.Name))
+		}
+
+		found = machine
+	}
+
+	if spec.Id != 0 && found == nil {
+		return nil, go_tilaa.NewClientError(fmt.Sprintf("no machine with id %d", spec.Id))
+	}
+
+	return found, nil
+}
+
+func findTemplate(templates []go_tilaa.Template, name string) *go_tilaa.Template {
+	for i := range templates {
+		if strings.EqualFold(templates[i].Name, name) {
+			return &templates[i]
+		}
+	}
+
+	return nil
+}
+
+func creationChanges(spec *MachineSpec) []Change {
+	changes := []Change{
+		{Field: "template", To: spec.Template},
+		{Field: "site", To: spec.Site},
+		{Field: "ram", To: strconv.Itoa(spec.Ram)},
+		{Field: "storage", To: fmt.Sprintf("%d (%s)", spec.Storage, spec.storageType())},
+	}
+
+	if spec.CpuCores != 0 {
+		changes = append(changes, Change{Field: "cpu_cores", To: strconv.Itoa(spec.CpuCores)})
+	}
+
+	return changes
+}
+
+func intChange(field string, from int, to int) Change {
+	return Change{Field: field, From: strconv.Itoa(from), To: strconv.Itoa(to)}
+}
+
+func atoi(value string) int {
+	number, _ := strconv.Atoi(value)
+
+	return number
+}
+
+func isCancelled(machine *go_tilaa.VirtualMachine) bool {
+	return machine.Cancelled != nil && !machine.Cancelled.IsZero() && machine.Cancelled.After(time.Now())
+}
+
+func sameIds(current []int, desired []int) bool {
+	if len(current) != len(desired) {
+		return false
+	}
+
+	seen := map[int]int{}
+
+	for _, id := range current {
+		seen[id]++
+	}
+
+	for _, id := range desired {
+		if seen[id] == 0 {
+			return false
+		}
+
+		seen[id]--
+	}
+
+	return true
+}
@@ -0,0 +1,120 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+	"github.com/pascal-splotches/go-tilaa/tilaamock"
+	"github.com/pascal-splotches/go-tilaa/tilaatest"
+)
+
+type contextKey struct{}
+
+func TestApplyEditsWithContextAndKeepsFailuresInOrder(t *testing.T) {
+	services := tilaamock.NewServices()
+	client, err := services.Client()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	services.VirtualMachine.Fail("Edit", errors.New("edit failed"))
+
+	var steps []Step
+
+	for i, name := range []string{"web3", "web1", "web2", "db1", "db2"} {
+		steps = append(steps, Step{
+			Action:  ActionEdit,
+			Name:    name,
+			Machine: &go_tilaa.VirtualMachine{Id: i + 1, Name: name},
+			Changes: []Change{{Field: "name", From: name, To: name + "-new"}},
+		})
+	}
+
+	reconciler := NewReconciler(client)
+	reconciler.ContinueOnError = true
+
+	ctx := context.WithValue(context.Background(), contextKey{}, "apply")
+	err = reconciler.Apply(ctx, &Plan{Steps: steps})
+
+	var applyError *ApplyError
+
+	if !errors.As(err, &applyError) {
+		t.Fatalf("expected an ApplyError, got %v", err)
+	}
+
+	expected := "Apply Error: edit web3 (edit failed); edit web1 (edit failed); edit web2 (edit failed); " +
+		"edit db1 (edit failed); edit db2 (edit failed)"
+
+	if applyError.Error() != expected {
+		t.Errorf("unexpected message %q", applyError.Error())
+	}
+
+	calls := services.VirtualMachine.CallsTo("Edit")
+
+	if len(calls) != len(steps) {
+		t.Fatalf("expected %d edits, got %d", len(steps), len(calls))
+	}
+
+	for _, call := range calls {
+		if call.Context.Value(contextKey{}) != "apply" {
+			t.Error("edit was not given the context of Apply")
+		}
+	}
+}
+
+func TestPlanAndApplyAgainstServer(t *testing.T) {
+	server := tilaatest.NewServer(tilaatest.WithTransitionDelay(20 * time.Millisecond))
+	defer server.Close()
+
+	client, err := server.Client()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	server.AddVirtualMachine(go_tilaa.VirtualMachine{
+		Name:     "web2",
+		Cpu:      go_tilaa.Cpu{Cores: 1, Cap: go_tilaa.MaxCpuCapPerCore},
+		Ram:      1024,
+		Storage:  go_tilaa.Storage{Size: 10, Type: go_tilaa.StorageTypeSsd},
+		Site:     go_tilaa.Site{Id: 1, Name: "AMS1"},
+		Template: go_tilaa.Template{Id: 1, Name: "Ubuntu 20.04"},
+	})
+
+	manifest := &Manifest{Machines: []MachineSpec{
+		{Name: "web1", Template: "Ubuntu 20.04", Site: "AMS1", Ram: 1024, Storage: 10, CpuCores: 1},
+		{Name: "web2", Template: "Ubuntu 20.04", Site: "AMS1", Ram: 2048, Storage: 10, CpuCores: 1},
+	}}
+
+	reconciler := NewReconciler(client)
+	plan, err := reconciler.Plan(ctx, manifest)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(plan.Steps) != 2 || plan.Steps[0].Action != ActionCreate || plan.Steps[1].Action != ActionEdit {
+		t.Fatalf("unexpected plan:\n%s", plan)
+	}
+
+	if err := reconciler.Apply(ctx, plan); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err = reconciler.Plan(ctx, manifest)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !plan.Empty() {
+		t.Errorf("expected the account to match the manifest, got:\n%s", plan)
+	}
+}
@@ -0,0 +1,326 @@
+package reconcile
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+)
+
+// ErrEmptySshKeyManifest is returned by PlanSshKeys for a manifest without keys, which would delete every unprotected
+// key of the account, unless AllowEmpty is set.
+var ErrEmptySshKeyManifest = errors.New("the SSH key manifest is empty")
+
+// SshKeyManifest describes the desired SSH keys of an account. Keys of the account which are not listed are deleted,
+// unless they are protected.
+type SshKeyManifest struct {
+	Keys []SshKeySpec
+
+	// Protect lists the labels or SHA256 fingerprints of keys which are never deleted, such as keys used by automation.
+	Protect []string
+
+	// AllowEmpty permits a manifest without keys, such as an empty authorized_keys file, which deletes every unprotected
+	// key.
+	AllowEmpty bool
+}
+
+// SshKeySpec is a single desired key. Keys are matched by fingerprint, a matching key with another label is relabeled.
+type SshKeySpec struct {
+	Label string
+	Key   *go_tilaa.PublicKey
+}
+
+// Add parses the key and adds it to the manifest. The comment of the key is used when the label is empty.
+func (manifest *SshKeyManifest) Add(label string, key string) error {
+	publicKey, err := go_tilaa.ParsePublicKey(key)
+
+	if err != nil {
+		return err
+	}
+
+	manifest.add(label, publicKey)
+
+	return nil
+}
+
+func (manifest *SshKeyManifest) add(label string, key *go_tilaa.PublicKey) {
+	if label == "" {
+		label = key.Comment()
+	}
+
+	if label == "" {
+		label = key.FingerprintSHA256()
+	}
+
+	manifest.Keys = append(manifest.Keys, SshKeySpec{Label: label, Key: key})
+}
+
+// LoadAuthorizedKeys reads the keys of an authorized_keys file, labelled by their comments. Empty lines and comments
+// are skipped.
+func LoadAuthorizedKeys(path string) (*SshKeyManifest, error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	manifest := &SshKeyManifest{}
+	scanner := bufio.NewScanner(file)
+	number := 0
+
+	for scanner.Scan() {
+		number++
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, err := go_tilaa.ParseAuthorizedKey(line)
+
+		if err != nil {
+			return nil, go_tilaa.NewClientError(fmt.Sprintf("%s:%d: %s", path, number, err.Error()))
+		}
+
+		manifest.add("", key)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// LoadSshKeyDir reads the keys of every .pub file in the directory, labelled by the name of the file without its
+// extension. A file may contain several keys, which then share the label.
+func LoadSshKeyDir(path string) (*SshKeyManifest, error) {
+	files, err := filepath.Glob(filepath.Join(path, "*.pub"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+
+	manifest := &SshKeyManifest{}
+
+	for _, file := range files {
+		contents, err := ioutil.ReadFile(file)
+
+		if err != nil {
+			return nil, err
+		}
+
+		label := strings.TrimSuffix(filepath.Base(file), ".pub")
+
+		for number, line := range strings.Split(string(contents), "\n") {
+			line = strings.TrimSpace(line)
+
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+
+			key, err := go_tilaa.ParseAuthorizedKey(line)
+
+			if err != nil {
+				return nil, go_tilaa.NewClientError(fmt.Sprintf("%s:%d: %s", file, number+1, err.Error()))
+			}
+
+			manifest.add(label, key)
+		}
+	}
+
+	return manifest, nil
+}
+
+// PlanSshKeys compares the manifest with the keys of the account and returns the steps needed to reconcile them.
+// Missing keys are created first and surplus keys deleted last, so rotated keys never leave the account without
+// access. Keys listed more than once, in the manifest or on the account, are deduplicated by fingerprint. Deletions are
+// destructive steps.
+func (reconciler *Reconciler) PlanSshKeys(ctx context.Context, manifest *SshKeyManifest) (*Plan, error) {
+	if len(manifest.Keys) == 0 && !manifest.AllowEmpty {
+		return nil, ErrEmptySshKeyManifest
+	}
+
+	sshKeys, err := reconciler.Client.SshKey.ListWithContext(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{}
+	desired := map[string]*SshKeySpec{}
+	var order []string
+
+	for i := range manifest.Keys {
+		spec := &manifest.Keys[i]
+		fingerprint := spec.Key.FingerprintSHA256()
+
+		if existing, ok := desired[fingerprint]; ok {
+			if existing.Label != spec.Label {
+				plan.warn("%s is listed as both %s and %s, using %s", fingerprint, existing.Label, spec.Label, existing.Label)
+			}
+
+			continue
+		}
+
+		desired[fingerprint] = spec
+		order = append(order, fingerprint)
+	}
+
+	current := map[string][]*go_tilaa.SshKey{}
+
+	for i := range *sshKeys {
+		sshKey := &(*sshKeys)[i]
+		fingerprint := sshKey.FingerprintSHA256()
+
+		current[fingerprint] = append(current[fingerprint], sshKey)
+	}
+
+	var edits, deletes []Step
+
+	for _, fingerprint := range order {
+		spec := desired[fingerprint]
+		matches := current[fingerprint]
+
+		if len(matches) == 0 {
+			sshKey := go_tilaa.NewSshKey(reconciler.Client)
+			sshKey.Label = spec.Label
+			sshKey.Key = spec.Key.String()
+
+			plan.add(Step{Action: ActionCreate, Name: spec.Label, SshKey: sshKey, Changes: []Change{{Field: "fingerprint", To: fingerprint}}})
+
+			continue
+		}
+
+		kept := keptSshKey(matches, spec.Label)
+
+		if kept.Label != spec.Label {
+			edits = append(edits, Step{Action: ActionEdit, Name: kept.Label, SshKey: kept, Changes: []Change{{Field: "label", From: kept.Label, To: spec.Label}}})
+		}
+
+		for _, sshKey := range matches {
+			if sshKey != kept {
+				deletes = append(deletes, deleteSshKeyStep(sshKey, Change{Field: "duplicate_of", To: kept.Label}))
+			}
+		}
+	}
+
+	for i := range *sshKeys {
+		sshKey := &(*sshKeys)[i]
+		fingerprint := sshKey.FingerprintSHA256()
+
+		if fingerprint != "" && desired[fingerprint] != nil {
+			continue
+		}
+
+		if fingerprint == "" && !isProtected(manifest.Protect, sshKey) {
+			plan.warn("%s can not be parsed and will be deleted", sshKey.Label)
+		}
+
+		deletes = append(deletes, deleteSshKeyStep(sshKey, Change{Field: "fingerprint", To: fingerprint}))
+	}
+
+	for _, step := range edits {
+		plan.add(step)
+	}
+
+	for _, step := range deletes {
+		if isProtected(manifest.Protect, step.SshKey) {
+			plan.warn("%s is protected and will not be deleted", step.Name)
+
+			continue
+		}
+
+		plan.add(step)
+	}
+
+	return plan, nil
+}
+
+// SyncSshKeys plans and applies the manifest, returning the plan. With dryRun set the plan is only computed. Keys are
+// only deleted when AllowDestructive is set.
+func (reconciler *Reconciler) SyncSshKeys(ctx context.Context, manifest *SshKeyManifest, dryRun bool) (*Plan, error) {
+	plan, err := reconciler.PlanSshKeys(ctx, manifest)
+
+	if err != nil || dryRun {
+		return plan, err
+	}
+
+	return plan, reconciler.Apply(ctx, plan)
+}
+
+func (reconciler *Reconciler) applySshKey(ctx context.Context, step Step) error {
+	switch step.Action {
+	case ActionCreate:
+		_, err := reconciler.Client.SshKey.AddWithContext(ctx, step.SshKey)
+
+		return err
+	case ActionEdit:
+		for _, change := range step.Changes {
+			if change.Field == "label" {
+				step.SshKey.Label = change.To
+			}
+		}
+
+		_, err := reconciler.Client.SshKey.EditWithContext(ctx, step.SshKey)
+
+		return err
+	case ActionDelete:
+		return reconciler.Client.SshKey.DeleteWithContext(ctx, step.SshKey)
+	}
+
+	return go_tilaa.NewClientError("unknown SSH key action " + string(step.Action))
+}
+
+// keptSshKey picks the key to keep out of several keys with the same fingerprint: the one with the desired label, or
+// else the oldest.
+func keptSshKey(matches []*go_tilaa.SshKey, label string) *go_tilaa.SshKey {
+	kept := matches[0]
+
+	for _, sshKey := range matches {
+		if sshKey.Label == label {
+			return sshKey
+		}
+
+		if sshKey.Created.Before(kept.Created) {
+			kept = sshKey
+		}
+	}
+
+	return kept
+}
+
+// deleteSshKeyStep describes the deletion of a key. The change is omitted when it has no value, such as the fingerprint
+// of a key which can not be parsed.
+func deleteSshKeyStep(sshKey *go_tilaa.SshKey, change Change) Step {
+	var changes []Change
+
+	if change.To != "" {
+		changes = []Change{change}
+	}
+
+	return Step{Action: ActionDelete, Name: sshKey.Label, SshKey: sshKey, Changes: changes, Destructive: true}
+}
+
+func isProtected(protect []string, sshKey *go_tilaa.SshKey) bool {
+	fingerprint := sshKey.FingerprintSHA256()
+
+	for _, entry := range protect {
+		if entry == sshKey.Label || (fingerprint != "" && entry == fingerprint) {
+			return true
+		}
+	}
+
+	return false
+}
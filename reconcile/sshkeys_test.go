@@ -0,0 +1,246 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+	"github.com/pascal-splotches/go-tilaa/tilaatest"
+)
+
+const (
+	testEd25519Key = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIDI8+ijWxnDjW8Ci9gcxno3sAgHH/KsvjbSKxLEahOAS"
+	testEcdsaKey   = "ecdsa-sha2-nistp256 AAAAE2VjZHNhLXNoYTItbmlzdHAyNTYAAAAIbmlzdHAyNTYAAABBBLwq3Y/0SVBoSF8raFeHZZv62qBSmrr+o6i3ZVhEf8U1QxZ1TPzjCSbXbzgE+3QHfvzqINL/IojxtaUj9ddfM/Y="
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "sshkeys")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, contents := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func manifestLabels(manifest *SshKeyManifest) []string {
+	labels := make([]string, len(manifest.Keys))
+
+	for i, spec := range manifest.Keys {
+		labels[i] = spec.Label
+	}
+
+	return labels
+}
+
+func TestLoadAuthorizedKeys(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"authorized_keys": "# keys\n\n" + testEd25519Key + " laptop\n  " + testEcdsaKey + "\n",
+		"invalid":         testEd25519Key + " laptop\nssh-ed25519 AAAA\n",
+		"empty":           "# no keys\n",
+	})
+	defer os.RemoveAll(dir)
+
+	manifest, err := LoadAuthorizedKeys(filepath.Join(dir, "authorized_keys"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecdsaKey, _ := go_tilaa.ParsePublicKey(testEcdsaKey)
+
+	if labels := manifestLabels(manifest); !reflect.DeepEqual(labels, []string{"laptop", ecdsaKey.FingerprintSHA256()}) {
+		t.Errorf("unexpected labels %v", labels)
+	}
+
+	if _, err := LoadAuthorizedKeys(filepath.Join(dir, "invalid")); err == nil || !strings.Contains(err.Error(), "invalid:2: ") {
+		t.Errorf("expected the invalid line to be reported, got %v", err)
+	}
+
+	if manifest, err := LoadAuthorizedKeys(filepath.Join(dir, "empty")); err != nil || len(manifest.Keys) != 0 {
+		t.Errorf("expected an empty manifest, got %v and %v", manifest, err)
+	}
+}
+
+func TestLoadSshKeyDir(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"bob.pub":   testEcdsaKey + " bob@host\n",
+		"alice.pub": testEd25519Key + " alice@laptop\n# old key\n" + testEcdsaKey + "\n",
+		"README":    "not a key\n",
+	})
+	defer os.RemoveAll(dir)
+
+	manifest, err := LoadSshKeyDir(dir)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if labels := manifestLabels(manifest); !reflect.DeepEqual(labels, []string{"alice", "alice", "bob"}) {
+		t.Errorf("unexpected labels %v", labels)
+	}
+}
+
+// newSshKeyServer seeds a server with a key to relabel, a duplicate of it, a protected key and a key which can not be
+// parsed.
+func newSshKeyServer(t *testing.T) (*tilaatest.Server, *Reconciler) {
+	server := tilaatest.NewServer()
+
+	server.AddSshKey(go_tilaa.SshKey{Label: "old-laptop", Key: testEd25519Key})
+	server.AddSshKey(go_tilaa.SshKey{Label: "copy", Key: testEd25519Key + " copy"})
+	server.AddSshKey(go_tilaa.SshKey{Label: "ci", Key: testEcdsaKey})
+	server.AddSshKey(go_tilaa.SshKey{Label: "broken", Key: "ssh-rsa"})
+
+	client, err := server.Client()
+
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+
+	return server, NewReconciler(client)
+}
+
+func testSshKeyManifest(t *testing.T) *SshKeyManifest {
+	manifest := &SshKeyManifest{Protect: []string{"ci"}}
+
+	if err := manifest.Add("laptop", testEd25519Key); err != nil {
+		t.Fatal(err)
+	}
+
+	return manifest
+}
+
+func TestPlanSshKeys(t *testing.T) {
+	server, reconciler := newSshKeyServer(t)
+	defer server.Close()
+
+	plan, err := reconciler.PlanSshKeys(context.Background(), testSshKeyManifest(t))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `~ edit old-laptop
+    label: old-laptop -> laptop
+- delete copy (destructive)
+    duplicate_of = old-laptop
+- delete broken (destructive)
+warning: broken can not be parsed and will be deleted
+warning: ci is protected and will not be deleted
+`
+
+	if plan.String() != expected {
+		t.Errorf("unexpected plan:\n%s", plan)
+	}
+
+	if len(plan.DestructiveSteps()) != 2 {
+		t.Errorf("expected both deletions to be destructive, got %v", plan.DestructiveSteps())
+	}
+}
+
+func TestPlanSshKeysRefusesEmptyManifest(t *testing.T) {
+	server, reconciler := newSshKeyServer(t)
+	defer server.Close()
+
+	manifest := &SshKeyManifest{Protect: []string{"ci"}}
+
+	if _, err := reconciler.PlanSshKeys(context.Background(), manifest); !errors.Is(err, ErrEmptySshKeyManifest) {
+		t.Errorf("expected ErrEmptySshKeyManifest, got %v", err)
+	}
+
+	manifest.AllowEmpty = true
+
+	plan, err := reconciler.PlanSshKeys(context.Background(), manifest)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var deleted []string
+
+	for _, step := range plan.DestructiveSteps() {
+		deleted = append(deleted, step.Name)
+	}
+
+	sort.Strings(deleted)
+
+	if !reflect.DeepEqual(deleted, []string{"broken", "copy", "old-laptop"}) {
+		t.Errorf("expected every unprotected key to be deleted, got %v", deleted)
+	}
+}
+
+func TestSyncSshKeys(t *testing.T) {
+	server, reconciler := newSshKeyServer(t)
+	defer server.Close()
+
+	ctx := context.Background()
+	manifest := testSshKeyManifest(t)
+
+	if err := manifest.Add("desktop", testEcdsaKey+" desktop"); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest.Protect = nil
+
+	var destructiveError *DestructiveStepsError
+
+	if _, err := reconciler.SyncSshKeys(ctx, manifest, false); !errors.As(err, &destructiveError) {
+		t.Fatalf("expected a DestructiveStepsError, got %v", err)
+	}
+
+	if sshKeys, err := reconciler.Client.SshKey.ListWithContext(ctx); err != nil || len(*sshKeys) != 4 {
+		t.Fatalf("expected the keys to be left alone, got %v and %v", sshKeys, err)
+	}
+
+	if _, err := reconciler.SyncSshKeys(ctx, manifest, true); err != nil {
+		t.Fatalf("expected a dry run to succeed, got %v", err)
+	}
+
+	reconciler.AllowDestructive = true
+
+	if _, err := reconciler.SyncSshKeys(ctx, manifest, false); err != nil {
+		t.Fatal(err)
+	}
+
+	sshKeys, err := reconciler.Client.SshKey.ListWithContext(ctx)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var labels []string
+
+	for _, sshKey := range *sshKeys {
+		labels = append(labels, sshKey.Label)
+	}
+
+	sort.Strings(labels)
+
+	if !reflect.DeepEqual(labels, []string{"desktop", "laptop"}) {
+		t.Errorf("unexpected keys %v", labels)
+	}
+
+	plan, err := reconciler.PlanSshKeys(ctx, manifest)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !plan.Empty() {
+		t.Errorf("expected the account to match the manifest, got:\n%s", plan)
+	}
+}
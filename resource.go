@@ -0,0 +1,74 @@
+package go_tilaa
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+)
+
+// ResourceId references another resource, such as an SshKey or Metadata, by ID. It decodes both a bare ID and an
+// object with an "id" field, as related resources are returned in either form.
+type ResourceId int
+
+type ResourceIds []ResourceId
+
+func (id *ResourceId) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+
+	if bytes.Equal(data, []byte("null")) {
+		*id = 0
+
+		return nil
+	}
+
+	if len(data) > 0 && data[0] == '{' {
+		var resource struct {
+			Id ResourceId `json:"id"`
+		}
+
+		if err := json.Unmarshal(data, &resource); err != nil {
+			return err
+		}
+
+		*id = resource.Id
+
+		return nil
+	}
+
+	if len(data) > 0 && data[0] == '"' {
+		var value string
+
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+
+		data = []byte(value)
+	}
+
+	if len(data) == 0 {
+		*id = 0
+
+		return nil
+	}
+
+	value, err := strconv.Atoi(string(data))
+
+	if err != nil {
+		return err
+	}
+
+	*id = ResourceId(value)
+
+	return nil
+}
+
+// Ints returns the IDs as plain integers.
+func (ids ResourceIds) Ints() []int {
+	values := make([]int, len(ids))
+
+	for i, id := range ids {
+		values[i] = int(id)
+	}
+
+	return values
+}
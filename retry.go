@@ -0,0 +1,168 @@
+package go_tilaa
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy describes how the Client retries requests which failed because of a transport error or a transient
+// API error. Only GET and DELETE requests are retried unless RetryPost is set, as POST requests are not idempotent.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts made for a request, including the first one. A value of 1 or less
+	// disables retries.
+	MaxAttempts int
+
+	// MinBackoff is the delay before the first retry. The delay doubles for every subsequent retry.
+	MinBackoff time.Duration
+
+	// MaxBackoff caps the delay between two attempts.
+	MaxBackoff time.Duration
+
+	// Jitter randomises each delay by up to the given fraction (0.0 - 1.0) in either direction.
+	Jitter float64
+
+	// RetryPost enables retries for POST requests.
+	RetryPost bool
+
+	// RetryableStatuses lists the HTTP status codes which are considered transient.
+	RetryableStatuses []int
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	MinBackoff:  500 * time.Millisecond,
+	MaxBackoff:  30 * time.Second,
+	Jitter:      0.2,
+	RetryPost:   false,
+	RetryableStatuses: []int{
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	},
+}
+
+// NoRetryPolicy performs every request exactly once.
+var NoRetryPolicy = RetryPolicy{MaxAttempts: 1}
+
+// WithRetryPolicy replaces the DefaultRetryPolicy used by the Client.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(client *Client) error {
+		client.RetryPolicy = policy
+
+		return nil
+	}
+}
+
+func (policy RetryPolicy) attempts(method string) int {
+	if policy.MaxAttempts <= 1 {
+		return 1
+	}
+
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete:
+		return policy.MaxAttempts
+	case http.MethodPost:
+		if policy.RetryPost {
+			return policy.MaxAttempts
+		}
+	}
+
+	return 1
+}
+
+func (policy RetryPolicy) shouldRetry(ctx context.Context, response *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if err != nil {
+		return true
+	}
+
+	for _, status := range policy.RetryableStatuses {
+		if response.StatusCode == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (policy RetryPolicy) backoff(attempt int, response *http.Response) time.Duration {
+	if delay, ok := retryAfter(response); ok {
+		return delay
+	}
+
+	delay := policy.MinBackoff
+
+	for i := 1; i < attempt && delay < policy.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	if policy.MaxBackoff > 0 && delay > policy.MaxBackoff {
+		delay = policy.MaxBackoff
+	}
+
+	if policy.Jitter > 0 {
+		delta := float64(delay) * policy.Jitter
+		delay = time.Duration(float64(delay) - delta + rand.Float64()*2*delta)
+	}
+
+	return delay
+}
+
+func retryAfter(response *http.Response) (time.Duration, bool) {
+	if response == nil {
+		return 0, false
+	}
+
+	header := response.Header.Get("Retry-After")
+
+	if header == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(header); err == nil {
+		delay := time.Until(date)
+
+		if delay < 0 {
+			delay = 0
+		}
+
+		return delay, true
+	}
+
+	return 0, false
+}
+
+func sleepWithContext(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func discardResponse(response *http.Response) {
+	if response == nil {
+		return
+	}
+
+	_, _ = io.Copy(ioutil.Discard, response.Body)
+	_ = response.Body.Close()
+}
@@ -0,0 +1,56 @@
+package go_tilaa_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+	"github.com/pascal-splotches/go-tilaa/tilaatest"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	policy := go_tilaa.RetryPolicy{
+		MaxAttempts:       3,
+		MinBackoff:        time.Millisecond,
+		MaxBackoff:        10 * time.Millisecond,
+		RetryableStatuses: []int{http.StatusServiceUnavailable},
+	}
+
+	server := tilaatest.NewServer()
+	defer server.Close()
+
+	client, err := server.Client(go_tilaa.WithRetryPolicy(policy))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	server.Inject(http.MethodGet, "virtual_machines", tilaatest.Fault{StatusCode: http.StatusServiceUnavailable, Times: 2})
+
+	if _, err := client.VirtualMachine.ListWithContext(ctx); err != nil {
+		t.Errorf("expected the request to be retried, got %v", err)
+	}
+
+	server.Inject(http.MethodGet, "templates", tilaatest.Fault{StatusCode: http.StatusServiceUnavailable, Times: 1, RetryAfter: time.Second})
+
+	started := time.Now()
+
+	if _, err := client.Template.ListWithContext(ctx); err != nil {
+		t.Errorf("expected the request to be retried, got %v", err)
+	}
+
+	if elapsed := time.Since(started); elapsed < time.Second {
+		t.Errorf("expected Retry-After to delay the retry by a second, retried after %s", elapsed)
+	}
+
+	server.Inject(http.MethodGet, "sites", tilaatest.Fault{StatusCode: http.StatusServiceUnavailable})
+
+	if _, err := client.Site.ListWithContext(ctx); err == nil {
+		t.Error("expected the request to fail once the attempts are used up")
+	}
+}
@@ -1,9 +1,12 @@
 package go_tilaa
 
+import "context"
+
 const sitesBasePath = "sites"
 
 type SiteServiceInterface interface {
 	List() (*[]Site, error)
+	ListWithContext(context.Context) (*[]Site, error)
 }
 
 type SiteService struct {
@@ -26,9 +29,13 @@ type SitesResponse struct {
 }
 
 func (service *SiteService) List() (*[]Site, error) {
+	return service.ListWithContext(context.Background())
+}
+
+func (service *SiteService) ListWithContext(ctx context.Context) (*[]Site, error) {
 	var response SitesResponse
 
-	_, err := service.client.Get(sitesBasePath, &response)
+	_, err := service.client.GetWithContext(ctx, sitesBasePath, &response)
 
 	if err != nil {
 		return nil, err
@@ -46,3 +53,8 @@ func (service *SiteService) List() (*[]Site, error) {
 
 	return &sites, err
 }
+
+// SetClient binds the site to the given client.
+func (site *Site) SetClient(client *Client) {
+	site.client = client
+}
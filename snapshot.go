@@ -1,6 +1,7 @@
 package go_tilaa
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"strconv"
@@ -11,11 +12,17 @@ const snapshotBasePath = "snapshots"
 
 type SnapshotServiceInterface interface {
 	List() (*[]Snapshot, error)
+	ListWithContext(context.Context) (*[]Snapshot, error)
 	Add(*VirtualMachine, string, bool, bool) (*Snapshot, error)
+	AddWithContext(context.Context, *VirtualMachine, string, bool, bool) (*Snapshot, error)
 	View(int) (*Snapshot, error)
+	ViewWithContext(context.Context, int) (*Snapshot, error)
 	Rename(*Snapshot, string) (*Snapshot, error)
+	RenameWithContext(context.Context, *Snapshot, string) (*Snapshot, error)
 	Delete(*Snapshot) error
+	DeleteWithContext(context.Context, *Snapshot) error
 	Restore(*VirtualMachine, *Snapshot) (*VirtualMachine, error)
+	RestoreWithContext(context.Context, *VirtualMachine, *Snapshot) (*VirtualMachine, error)
 }
 
 type SnapshotService struct {
@@ -38,11 +45,16 @@ type Snapshot struct {
 
 type SnapshotStatus string
 
-// TODO: Figure out snapshot statuses
+// A snapshot ends up in SnapshotStatusSuccess or SnapshotStatusFailed, every other status is treated as in progress.
 const (
-	SnapshotStatusSuccess = "success"
+	SnapshotStatusSuccess SnapshotStatus = "success"
+	SnapshotStatusFailed  SnapshotStatus = "failed"
 )
 
+// snapshotClockSkew is the difference allowed between the local clock and the creation time reported by the API when
+// resolving a newly created snapshot.
+const snapshotClockSkew = 5 * time.Minute
+
 type SnapshotResponse struct {
 	Status   ResponseStatus `json:"status"`
 	Message  string         `json:"message,omitempty"`
@@ -56,9 +68,13 @@ type SnapshotsResponse struct {
 }
 
 func (service *SnapshotService) List() (*[]Snapshot, error) {
+	return service.ListWithContext(context.Background())
+}
+
+func (service *SnapshotService) ListWithContext(ctx context.Context) (*[]Snapshot, error) {
 	var response SnapshotsResponse
 
-	_, err := service.client.Get(snapshotBasePath, &response)
+	_, err := service.client.GetWithContext(ctx, snapshotBasePath, &response)
 
 	if err != nil {
 		return nil, err
@@ -78,13 +94,21 @@ func (service *SnapshotService) List() (*[]Snapshot, error) {
 }
 
 func (service *SnapshotService) Add(machine *VirtualMachine, name string, online bool, overwrite bool) (*Snapshot, error) {
-	return service.client.VirtualMachine.CreateSnapshot(machine, name, online, overwrite)
+	return service.AddWithContext(context.Background(), machine, name, online, overwrite)
+}
+
+func (service *SnapshotService) AddWithContext(ctx context.Context, machine *VirtualMachine, name string, online bool, overwrite bool) (*Snapshot, error) {
+	return service.client.VirtualMachine.CreateSnapshotWithContext(ctx, machine, name, online, overwrite)
 }
 
 func (service *SnapshotService) View(snapshotId int) (*Snapshot, error) {
+	return service.ViewWithContext(context.Background(), snapshotId)
+}
+
+func (service *SnapshotService) ViewWithContext(ctx context.Context, snapshotId int) (*Snapshot, error) {
 	var response SnapshotResponse
 
-	_, err := service.client.Get(service.path(strconv.Itoa(snapshotId)), &response)
+	_, err := service.client.GetWithContext(ctx, service.path(strconv.Itoa(snapshotId)), &response)
 
 	if err != nil {
 		return NewSnapshot(service.client), err
@@ -102,13 +126,17 @@ func (service *SnapshotService) View(snapshotId int) (*Snapshot, error) {
 }
 
 func (service *SnapshotService) Rename(snapshot *Snapshot, name string) (*Snapshot, error) {
+	return service.RenameWithContext(context.Background(), snapshot, name)
+}
+
+func (service *SnapshotService) RenameWithContext(ctx context.Context, snapshot *Snapshot, name string) (*Snapshot, error) {
 	payload := &url.Values{
 		"name": {name},
 	}
 
 	var response StatusResponse
 
-	_, err := service.client.Post(service.path(strconv.Itoa(snapshot.Id)), payload, &response)
+	_, err := service.client.PostWithContext(ctx, service.path(strconv.Itoa(snapshot.Id)), payload, &response)
 
 	if err != nil {
 		return snapshot, err
@@ -124,9 +152,13 @@ func (service *SnapshotService) Rename(snapshot *Snapshot, name string) (*Snapsh
 }
 
 func (service *SnapshotService) Delete(snapshot *Snapshot) error {
+	return service.DeleteWithContext(context.Background(), snapshot)
+}
+
+func (service *SnapshotService) DeleteWithContext(ctx context.Context, snapshot *Snapshot) error {
 	var response StatusResponse
 
-	_, err := service.client.Delete(service.path(strconv.Itoa(snapshot.Id)), &response)
+	_, err := service.client.DeleteWithContext(ctx, service.path(strconv.Itoa(snapshot.Id)), &response)
 
 	if err != nil {
 		return err
@@ -140,19 +172,61 @@ func (service *SnapshotService) Delete(snapshot *Snapshot) error {
 }
 
 func (service *SnapshotService) Restore(machine *VirtualMachine, snapshot *Snapshot) (*VirtualMachine, error) {
-	return service.client.VirtualMachine.RestoreSnapshot(machine, snapshot)
+	return service.RestoreWithContext(context.Background(), machine, snapshot)
+}
+
+func (service *SnapshotService) RestoreWithContext(ctx context.Context, machine *VirtualMachine, snapshot *Snapshot) (*VirtualMachine, error) {
+	return service.client.VirtualMachine.RestoreSnapshotWithContext(ctx, machine, snapshot)
 }
 
 func (service *SnapshotService) path(path string) string {
 	return fmt.Sprintf("%s/%s", snapshotBasePath, path)
 }
 
+// Create creates the snapshot of the machine. The snapshot is only populated with its ID and status when the client
+// resolves new snapshots, see WithSnapshotResolution.
 func (snapshot *Snapshot) Create(machine *VirtualMachine, online bool, overwrite bool) error {
-	_, err := machine.CreateSnapshot(snapshot.Name, online, overwrite)
+	created, err := machine.CreateSnapshot(snapshot.Name, online, overwrite)
 
-	// TODO: Endpoint does not return Snapshot ID after creation
+	if err != nil {
+		return err
+	}
 
-	return err
+	if created.Id != 0 {
+		snapshot.update(created)
+	}
+
+	return nil
+}
+
+func (snapshot *Snapshot) Refresh() error {
+	return snapshot.RefreshWithContext(context.Background())
+}
+
+func (snapshot *Snapshot) RefreshWithContext(ctx context.Context) error {
+	if snapshot.Id == 0 {
+		return NewSnapshotNotCreatedError()
+	}
+
+	update, err := snapshot.client.Snapshot.ViewWithContext(ctx, snapshot.Id)
+
+	if err != nil {
+		return err
+	}
+
+	snapshot.update(update)
+
+	return nil
+}
+
+func (snapshot *Snapshot) update(update *Snapshot) {
+	snapshot.Id = update.Id
+	snapshot.Name = update.Name
+	snapshot.Storage = update.Storage
+	snapshot.Ram = update.Ram
+	snapshot.Template = update.Template
+	snapshot.Status = update.Status
+	snapshot.Created = update.Created
 }
 
 func (snapshot *Snapshot) Rename(name string) error {
@@ -196,3 +270,27 @@ func (snapshot *Snapshot) RestoreToVirtualMachine(machine *VirtualMachine) error
 func NewSnapshot(client *Client) *Snapshot {
 	return &Snapshot{client: client}
 }
+
+// IsFinal reports whether the snapshot is done, either successfully or not.
+func (status SnapshotStatus) IsFinal() bool {
+	return status == SnapshotStatusSuccess || status == SnapshotStatusFailed
+}
+
+func (status SnapshotStatus) IsFailed() bool {
+	return status == SnapshotStatusFailed
+}
+
+// WithSnapshotResolution makes CreateSnapshot resolve the snapshot it created, which the API does not return, and
+// wait until it is done using the given Waiter. Use a context with a deadline to bound the wait.
+func WithSnapshotResolution(waiter Waiter) Option {
+	return func(client *Client) error {
+		client.snapshotWaiter = &waiter
+
+		return nil
+	}
+}
+
+// SetClient binds the snapshot to the given client, which is used by methods such as Rename and Delete.
+func (snapshot *Snapshot) SetClient(client *Client) {
+	snapshot.client = client
+}
@@ -0,0 +1,41 @@
+package go_tilaa_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+	"github.com/pascal-splotches/go-tilaa/tilaatest"
+)
+
+func TestCreateSnapshotWithResolution(t *testing.T) {
+	server := tilaatest.NewServer(tilaatest.WithTransitionDelay(20 * time.Millisecond))
+	defer server.Close()
+
+	client, err := server.Client(go_tilaa.WithSnapshotResolution(testWaiter))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	machineId := server.AddVirtualMachine(go_tilaa.VirtualMachine{Name: "web1"})
+	machine, err := client.VirtualMachine.ViewWithContext(ctx, machineId)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, err := client.VirtualMachine.CreateSnapshotWithContext(ctx, machine, "before-upgrade", true, false)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if snapshot.Id == 0 || snapshot.Name != "before-upgrade" || snapshot.Status != go_tilaa.SnapshotStatusSuccess {
+		t.Errorf("snapshot was not resolved: %+v", snapshot)
+	}
+}
@@ -1,9 +1,11 @@
 package go_tilaa
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -11,10 +13,15 @@ const sshKeyBasePath = "ssh_keys"
 
 type SshKeyServiceInterface interface {
 	List() (*[]SshKey, error)
+	ListWithContext(context.Context) (*[]SshKey, error)
 	Add(*SshKey) (*SshKey, error)
+	AddWithContext(context.Context, *SshKey) (*SshKey, error)
 	View(int) (*SshKey, error)
+	ViewWithContext(context.Context, int) (*SshKey, error)
 	Edit(*SshKey) (*SshKey, error)
+	EditWithContext(context.Context, *SshKey) (*SshKey, error)
 	Delete(*SshKey) error
+	DeleteWithContext(context.Context, *SshKey) error
 }
 
 type SshKeyService struct {
@@ -47,9 +54,13 @@ type SshKeysResponse struct {
 }
 
 func (service *SshKeyService) List() (*[]SshKey, error) {
+	return service.ListWithContext(context.Background())
+}
+
+func (service *SshKeyService) ListWithContext(ctx context.Context) (*[]SshKey, error) {
 	var response SshKeysResponse
 
-	_, err := service.client.Get(sshKeyBasePath, &response)
+	_, err := service.client.GetWithContext(ctx, sshKeyBasePath, &response)
 
 	if err != nil {
 		return nil, err
@@ -69,33 +80,84 @@ func (service *SshKeyService) List() (*[]SshKey, error) {
 }
 
 func (service *SshKeyService) Add(sshKey *SshKey) (*SshKey, error) {
+	return service.AddWithContext(context.Background(), sshKey)
+}
+
+func (service *SshKeyService) AddWithContext(ctx context.Context, sshKey *SshKey) (*SshKey, error) {
 	if err := sshKey.Validate(); err != nil {
 		return NewSshKey(service.client), err
 	}
 
+	sshKey.normalize()
+
 	payload := sshKey.Payload()
 
 	var response StatusResponse
 
-	_, err := service.client.Post(sshKeyBasePath, payload, &response)
+	_, err := service.client.PostWithContext(ctx, sshKeyBasePath, payload, &response)
 
 	if err != nil {
 		return NewSshKey(service.client), err
 	}
 
 	if response.Status == ResponseError {
-		err = NewApiError(response.Message)
+		return sshKey, NewApiError(response.Message)
 	}
 
-	// TODO: SSH Key endpoint does not return ID of the created key
+	// The endpoint does not return the created key, so it is looked up by its label and fingerprint
+	created, err := service.findCreated(ctx, sshKey)
 
-	return sshKey, err
+	if err != nil {
+		return sshKey, err
+	}
+
+	sshKey.Id = created.Id
+	sshKey.UserId = created.UserId
+	sshKey.Created = created.Created
+	sshKey.Modified = created.Modified
+
+	return sshKey, nil
+}
+
+// findCreated returns the most recently created key matching the label and fingerprint of the given key.
+func (service *SshKeyService) findCreated(ctx context.Context, sshKey *SshKey) (*SshKey, error) {
+	sshKeys, err := service.ListWithContext(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint := sshKey.FingerprintSHA256()
+
+	var found *SshKey
+
+	for i := range *sshKeys {
+		candidate := &(*sshKeys)[i]
+
+		if candidate.Label != sshKey.Label || fingerprint == "" || candidate.FingerprintSHA256() != fingerprint {
+			continue
+		}
+
+		if found == nil || candidate.Created.After(found.Created) || (candidate.Created.Equal(found.Created) && candidate.Id > found.Id) {
+			found = candidate
+		}
+	}
+
+	if found == nil {
+		return nil, NewSshKeyNotResolvedError(sshKey.Label)
+	}
+
+	return found, nil
 }
 
 func (service *SshKeyService) View(sshKeyId int) (*SshKey, error) {
+	return service.ViewWithContext(context.Background(), sshKeyId)
+}
+
+func (service *SshKeyService) ViewWithContext(ctx context.Context, sshKeyId int) (*SshKey, error) {
 	var response SshKeyResponse
 
-	_, err := service.client.Get(service.path(strconv.Itoa(sshKeyId)), &response)
+	_, err := service.client.GetWithContext(ctx, service.path(strconv.Itoa(sshKeyId)), &response)
 
 	if err != nil {
 		return NewSshKey(service.client), err
@@ -113,15 +175,23 @@ func (service *SshKeyService) View(sshKeyId int) (*SshKey, error) {
 }
 
 func (service *SshKeyService) Edit(sshKey *SshKey) (*SshKey, error) {
-	if err := sshKey.Validate(); err != nil {
+	return service.EditWithContext(context.Background(), sshKey)
+}
+
+// EditWithContext saves the label and key. Weak keys are not rejected, so keys stored before they were, such as DSA
+// keys, can still be relabeled.
+func (service *SshKeyService) EditWithContext(ctx context.Context, sshKey *SshKey) (*SshKey, error) {
+	if err := sshKey.validate(false); err != nil {
 		return sshKey, err
 	}
 
+	sshKey.normalize()
+
 	payload := sshKey.Payload()
 
 	var response StatusResponse
 
-	_, err := service.client.Post(service.path(strconv.Itoa(sshKey.Id)), payload, &response)
+	_, err := service.client.PostWithContext(ctx, service.path(strconv.Itoa(sshKey.Id)), payload, &response)
 
 	if err != nil {
 		return sshKey, err
@@ -135,9 +205,13 @@ func (service *SshKeyService) Edit(sshKey *SshKey) (*SshKey, error) {
 }
 
 func (service *SshKeyService) Delete(sshKey *SshKey) error {
+	return service.DeleteWithContext(context.Background(), sshKey)
+}
+
+func (service *SshKeyService) DeleteWithContext(ctx context.Context, sshKey *SshKey) error {
 	var response StatusResponse
 
-	_, err := service.client.Delete(service.path(strconv.Itoa(sshKey.Id)), &response)
+	_, err := service.client.DeleteWithContext(ctx, service.path(strconv.Itoa(sshKey.Id)), &response)
 
 	if err != nil {
 		return err
@@ -188,11 +262,89 @@ func (sshKey *SshKey) Payload() *url.Values {
 	}
 }
 
+// Validate checks the label and parses the key, returning a ValidationError listing every problem found.
 func (sshKey *SshKey) Validate() error {
-	// TODO: Implement validation for all the fields
+	return sshKey.validate(true)
+}
+
+// validate checks the label and the key, rejecting weak keys when checkStrength is set.
+func (sshKey *SshKey) validate(checkStrength bool) error {
+	validationError := NewValidationError()
+
+	if strings.TrimSpace(sshKey.Label) == "" {
+		validationError.Add("label", "can not be empty")
+	}
+
+	publicKey, err := parsePublicKey(sshKey.Key)
+
+	if err == nil && checkStrength {
+		err = publicKey.checkStrength()
+	}
+
+	if err != nil {
+		validationError.Add("key", "%s", publicKeyErrorReason(err))
+	}
+
+	if validationError.HasErrors() {
+		return validationError
+	}
+
 	return nil
 }
 
+// normalize rewrites a valid key with single spaces between its type, data and comment.
+func (sshKey *SshKey) normalize() {
+	if publicKey, err := parsePublicKey(sshKey.Key); err == nil {
+		sshKey.Key = publicKey.String()
+	}
+}
+
+// PublicKey parses the key. Weak keys, which may have been stored before they were rejected, are parsed as well.
+func (sshKey *SshKey) PublicKey() (*PublicKey, error) {
+	return parsePublicKey(sshKey.Key)
+}
+
+// Type returns the type of the key, e.g. "ssh-ed25519", or an empty string when the key can not be parsed.
+func (sshKey *SshKey) Type() string {
+	if publicKey, err := sshKey.PublicKey(); err == nil {
+		return publicKey.Type()
+	}
+
+	return ""
+}
+
+// Bits returns the size of the key, or 0 when the key can not be parsed.
+func (sshKey *SshKey) Bits() int {
+	if publicKey, err := sshKey.PublicKey(); err == nil {
+		return publicKey.Bits()
+	}
+
+	return 0
+}
+
+// FingerprintSHA256 returns the SHA256 fingerprint of the key, or an empty string when the key can not be parsed.
+func (sshKey *SshKey) FingerprintSHA256() string {
+	if publicKey, err := sshKey.PublicKey(); err == nil {
+		return publicKey.FingerprintSHA256()
+	}
+
+	return ""
+}
+
+// FingerprintMD5 returns the MD5 fingerprint of the key, or an empty string when the key can not be parsed.
+func (sshKey *SshKey) FingerprintMD5() string {
+	if publicKey, err := sshKey.PublicKey(); err == nil {
+		return publicKey.FingerprintMD5()
+	}
+
+	return ""
+}
+
 func NewSshKey(client *Client) *SshKey {
 	return &SshKey{client: client}
 }
+
+// SetClient binds the key to the given client, which is used by methods such as Commit and Delete.
+func (sshKey *SshKey) SetClient(client *Client) {
+	sshKey.client = client
+}
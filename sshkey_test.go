@@ -0,0 +1,103 @@
+package go_tilaa_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+	"github.com/pascal-splotches/go-tilaa/tilaatest"
+)
+
+const (
+	testDsaKey     = "ssh-dss AAAAB3NzaC1kc3MAAACBAJ2Whl0DNdk2JzNiy/GX1Swr860SjwTXqLaJPjlx0M1fD1l6CMM2ZThpfbU9Z3qChAkc0h/yz66f7xwKJd/WdvpbUTIBt/KjOlWg2bwIeR7i0YzoxxSO06RTxLP9Af4mg3SoJht7MnIU5DS1t07cVygUKS4ACMCIOIHzigYBdBKPAAAAFQCDMM1uDyJhx7HCoz9WEgfGa838LQAAAIEAiuXVPTHM5fmk2qUBbpN/gzBRrS4/y05oDkkB4LPnieMSWcGnkdp1DdYkp5/vK/QJTNkFKBhHVAXhCPv7MNUvqj8hjBwuL1GQ6uNQRqxPtjJCEKcc8tIsuBNkItc6+kDTkycT+bQ18fjan4fb+EohlBQjkxlA5HHvvz6Ohh4B1pIAAACAIW9Lps2xbXZAEYq10w1eXXV7KvvcnpSrZ7yZkNIHpwdbZy1te7Ls/mx8jjMl97kZpzEBeyxzPIltEkIWeP044QhhGVFKAG5dh4YmvwIeIU/GMZTL97cHvYnIYEzSUi9jL9qpjFd4Eq0U2VV16N9QXdsBZIXU38wMpR19VcD/QMo= dsa@host"
+	testEd25519Key = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIDI8+ijWxnDjW8Ci9gcxno3sAgHH/KsvjbSKxLEahOAS me@host"
+	testRsa1024Key = "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAAAgQDT8gVmRZ0LDyp07WLTOrnvknncojQ8TIyJno9w0zd7F+C2iXT7NjGEqrk60SRyZsRJnLFDHIN497UUGXcvWlnIKGqdhkThhcDfa43UENEMDVvsyAIoC9MThgqOssBgoFS00+jUmHtCfLzWtf6wSj+pb8jXpMxZT9jJ8oxoEu4Evw== rsa@host"
+)
+
+func TestSshKeyEditRelabelsWeakKeys(t *testing.T) {
+	server := tilaatest.NewServer()
+	defer server.Close()
+
+	client, err := server.Client()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{testDsaKey, testRsa1024Key} {
+		sshKeyId := server.AddSshKey(go_tilaa.SshKey{Label: "old", Key: key})
+		sshKey, err := client.SshKey.View(sshKeyId)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sshKey.Label = "new"
+
+		if _, err := client.SshKey.Edit(sshKey); err != nil {
+			t.Errorf("relabeling %s: %v", sshKey.Type(), err)
+		}
+
+		if sshKey.FingerprintSHA256() == "" {
+			t.Errorf("no fingerprint for %s", sshKey.Type())
+		}
+
+		var validationError *go_tilaa.ValidationError
+
+		if _, err := client.SshKey.Add(&go_tilaa.SshKey{Label: "weak", Key: key}); !errors.As(err, &validationError) {
+			t.Errorf("adding %s: expected a ValidationError, got %v", sshKey.Type(), err)
+		}
+	}
+}
+
+func TestStoredDsaKeyFingerprint(t *testing.T) {
+	sshKey := &go_tilaa.SshKey{Key: testDsaKey}
+
+	if fingerprint := sshKey.FingerprintSHA256(); fingerprint != "SHA256:NmB8Nt7pF9ISVZfNqobj5kGm+TW8GmE1mNF4gnCT6r0" {
+		t.Errorf("unexpected fingerprint %q", fingerprint)
+	}
+
+	if bits := sshKey.Bits(); bits != 1024 {
+		t.Errorf("expected 1024 bits, got %d", bits)
+	}
+}
+
+func TestSshKeyAddResolvesId(t *testing.T) {
+	server := tilaatest.NewServer()
+	defer server.Close()
+
+	client, err := server.Client()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ids := map[int]bool{}
+
+	for _, label := range []string{"laptop", "desktop"} {
+		sshKey := go_tilaa.NewSshKey(client)
+		sshKey.Label = label
+		sshKey.Key = testEd25519Key
+
+		if _, err := client.SshKey.AddWithContext(ctx, sshKey); err != nil {
+			t.Fatal(err)
+		}
+
+		stored, err := client.SshKey.ViewWithContext(ctx, sshKey.Id)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if stored.Label != label || ids[sshKey.Id] {
+			t.Errorf("%s resolved to key %d labeled %s", label, sshKey.Id, stored.Label)
+		}
+
+		ids[sshKey.Id] = true
+	}
+}
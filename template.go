@@ -1,9 +1,12 @@
 package go_tilaa
 
+import "context"
+
 const templatesBasePath = "templates"
 
 type TemplateServiceInterface interface {
 	List() (*[]Template, error)
+	ListWithContext(context.Context) (*[]Template, error)
 }
 
 type TemplateService struct {
@@ -30,9 +33,13 @@ type TemplatesResponse struct {
 }
 
 func (service *TemplateService) List() (*[]Template, error) {
+	return service.ListWithContext(context.Background())
+}
+
+func (service *TemplateService) ListWithContext(ctx context.Context) (*[]Template, error) {
 	var response TemplatesResponse
 
-	_, err := service.client.Get(templatesBasePath, &response)
+	_, err := service.client.GetWithContext(ctx, templatesBasePath, &response)
 
 	if err != nil {
 		return nil, err
@@ -50,3 +57,8 @@ func (service *TemplateService) List() (*[]Template, error) {
 
 	return &templates, err
 }
+
+// SetClient binds the template to the given client.
+func (template *Template) SetClient(client *Client) {
+	template.client = client
+}
@@ -0,0 +1,114 @@
+package tilaamock
+
+import (
+	"context"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+)
+
+// PresetService mocks go_tilaa.PresetServiceInterface. Without a ListFunc it returns empty presets.
+type PresetService struct {
+	Recorder
+
+	// Client is bound to the returned presets when set.
+	Client *go_tilaa.Client
+
+	ListFunc func(context.Context) (*go_tilaa.Presets, error)
+}
+
+// TemplateService mocks go_tilaa.TemplateServiceInterface. Without a ListFunc it returns no templates.
+type TemplateService struct {
+	Recorder
+
+	// Client is bound to every returned template when set.
+	Client *go_tilaa.Client
+
+	ListFunc func(context.Context) (*[]go_tilaa.Template, error)
+}
+
+// SiteService mocks go_tilaa.SiteServiceInterface. Without a ListFunc it returns no sites.
+type SiteService struct {
+	Recorder
+
+	// Client is bound to every returned site when set.
+	Client *go_tilaa.Client
+
+	ListFunc func(context.Context) (*[]go_tilaa.Site, error)
+}
+
+var _ go_tilaa.PresetServiceInterface = &PresetService{}
+var _ go_tilaa.TemplateServiceInterface = &TemplateService{}
+var _ go_tilaa.SiteServiceInterface = &SiteService{}
+
+func (mock *PresetService) List() (*go_tilaa.Presets, error) {
+	return mock.ListWithContext(context.Background())
+}
+
+func (mock *PresetService) ListWithContext(ctx context.Context) (*go_tilaa.Presets, error) {
+	if err := mock.record(ctx, "List"); err != nil {
+		return nil, err
+	}
+
+	if mock.ListFunc == nil {
+		presets := &go_tilaa.Presets{}
+		presets.SetClient(mock.Client)
+
+		return presets, nil
+	}
+
+	presets, err := mock.ListFunc(ctx)
+
+	if presets != nil && mock.Client != nil {
+		presets.SetClient(mock.Client)
+	}
+
+	return presets, err
+}
+
+func (mock *TemplateService) List() (*[]go_tilaa.Template, error) {
+	return mock.ListWithContext(context.Background())
+}
+
+func (mock *TemplateService) ListWithContext(ctx context.Context) (*[]go_tilaa.Template, error) {
+	if err := mock.record(ctx, "List"); err != nil {
+		return nil, err
+	}
+
+	if mock.ListFunc == nil {
+		return &[]go_tilaa.Template{}, nil
+	}
+
+	templates, err := mock.ListFunc(ctx)
+
+	if templates != nil && mock.Client != nil {
+		for i := range *templates {
+			(*templates)[i].SetClient(mock.Client)
+		}
+	}
+
+	return templates, err
+}
+
+func (mock *SiteService) List() (*[]go_tilaa.Site, error) {
+	return mock.ListWithContext(context.Background())
+}
+
+func (mock *SiteService) ListWithContext(ctx context.Context) (*[]go_tilaa.Site, error) {
+	if err := mock.record(ctx, "List"); err != nil {
+		return nil, err
+	}
+
+	if mock.ListFunc == nil {
+		return &[]go_tilaa.Site{}, nil
+	}
+
+	sites, err := mock.ListFunc(ctx)
+
+	if sites != nil && mock.Client != nil {
+		for i := range *sites {
+			(*sites)[i].SetClient(mock.Client)
+		}
+	}
+
+	return sites, err
+}
@@ -0,0 +1,124 @@
+package tilaamock
+
+import (
+	"context"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+)
+
+// MetadataService mocks go_tilaa.MetadataServiceInterface. Methods without a Func succeed, returning the given
+// metadata or an empty result.
+type MetadataService struct {
+	Recorder
+
+	// Client is bound to all returned metadata when set.
+	Client *go_tilaa.Client
+
+	ListFunc   func(context.Context) (*[]go_tilaa.Metadata, error)
+	AddFunc    func(context.Context, *go_tilaa.Metadata) (*go_tilaa.Metadata, error)
+	ViewFunc   func(context.Context, int) (*go_tilaa.Metadata, error)
+	EditFunc   func(context.Context, *go_tilaa.Metadata) (*go_tilaa.Metadata, error)
+	DeleteFunc func(context.Context, *go_tilaa.Metadata) error
+}
+
+var _ go_tilaa.MetadataServiceInterface = &MetadataService{}
+
+func (mock *MetadataService) List() (*[]go_tilaa.Metadata, error) {
+	return mock.ListWithContext(context.Background())
+}
+
+func (mock *MetadataService) ListWithContext(ctx context.Context) (*[]go_tilaa.Metadata, error) {
+	if err := mock.record(ctx, "List"); err != nil {
+		return nil, err
+	}
+
+	if mock.ListFunc == nil {
+		return &[]go_tilaa.Metadata{}, nil
+	}
+
+	metadata, err := mock.ListFunc(ctx)
+
+	if metadata != nil && mock.Client != nil {
+		for i := range *metadata {
+			(*metadata)[i].SetClient(mock.Client)
+		}
+	}
+
+	return metadata, err
+}
+
+func (mock *MetadataService) Add(metadata *go_tilaa.Metadata) (*go_tilaa.Metadata, error) {
+	return mock.AddWithContext(context.Background(), metadata)
+}
+
+func (mock *MetadataService) AddWithContext(ctx context.Context, metadata *go_tilaa.Metadata) (*go_tilaa.Metadata, error) {
+	if err := mock.record(ctx, "Add", metadata); err != nil {
+		return go_tilaa.NewMetadata(mock.Client), err
+	}
+
+	if mock.AddFunc == nil {
+		return metadata, nil
+	}
+
+	return mock.bind(mock.AddFunc(ctx, metadata))
+}
+
+func (mock *MetadataService) View(metadataId int) (*go_tilaa.Metadata, error) {
+	return mock.ViewWithContext(context.Background(), metadataId)
+}
+
+func (mock *MetadataService) ViewWithContext(ctx context.Context, metadataId int) (*go_tilaa.Metadata, error) {
+	if err := mock.record(ctx, "View", metadataId); err != nil {
+		return go_tilaa.NewMetadata(mock.Client), err
+	}
+
+	if mock.ViewFunc == nil {
+		metadata := go_tilaa.NewMetadata(mock.Client)
+		metadata.Id = metadataId
+
+		return metadata, nil
+	}
+
+	return mock.bind(mock.ViewFunc(ctx, metadataId))
+}
+
+func (mock *MetadataService) Edit(metadata *go_tilaa.Metadata) (*go_tilaa.Metadata, error) {
+	return mock.EditWithContext(context.Background(), metadata)
+}
+
+func (mock *MetadataService) EditWithContext(ctx context.Context, metadata *go_tilaa.Metadata) (*go_tilaa.Metadata, error) {
+	if err := mock.record(ctx, "Edit", metadata); err != nil {
+		return metadata, err
+	}
+
+	if mock.EditFunc == nil {
+		return metadata, nil
+	}
+
+	return mock.bind(mock.EditFunc(ctx, metadata))
+}
+
+func (mock *MetadataService) Delete(metadata *go_tilaa.Metadata) error {
+	return mock.DeleteWithContext(context.Background(), metadata)
+}
+
+func (mock *MetadataService) DeleteWithContext(ctx context.Context, metadata *go_tilaa.Metadata) error {
+	if err := mock.record(ctx, "Delete", metadata); err != nil {
+		return err
+	}
+
+	if mock.DeleteFunc == nil {
+		return nil
+	}
+
+	return mock.DeleteFunc(ctx, metadata)
+}
+
+// bind binds the metadata to the client of the mock and passes the results through.
+func (mock *MetadataService) bind(metadata *go_tilaa.Metadata, err error) (*go_tilaa.Metadata, error) {
+	if metadata != nil && mock.Client != nil {
+		metadata.SetClient(mock.Client)
+	}
+
+	return metadata, err
+}
@@ -0,0 +1,164 @@
+// Package tilaamock provides configurable mock implementations of every service interface of go-tilaa. Each mock
+// records its calls, returns canned responses configured through its Func fields and can be told to fail.
+//
+// Services bundles a mock of every service and builds a Client using them, binding every model returned by the mocks
+// to that Client so model methods such as Start or Commit are routed through the mocks as well.
+package tilaamock
+
+import (
+	"context"
+	"sync"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+)
+
+// Call is a recorded call of a service method. Calls of a method and its WithContext variant are both recorded under
+// the name of the method without the suffix, calls without a context record context.Background().
+type Call struct {
+	Method  string
+	Context context.Context
+	Args    []interface{}
+}
+
+// Recorder records the calls of a mock and holds the errors injected into it. It is safe for concurrent use.
+type Recorder struct {
+	mutex  sync.Mutex
+	calls  []Call
+	errors map[string]error
+}
+
+// Calls returns every recorded call in order.
+func (recorder *Recorder) Calls() []Call {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	return append([]Call{}, recorder.calls...)
+}
+
+// CallsTo returns the recorded calls of the given method, e.g. "List".
+func (recorder *Recorder) CallsTo(method string) []Call {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	var calls []Call
+
+	for _, call := range recorder.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+
+	return calls
+}
+
+// CallCount returns the number of recorded calls of the given method.
+func (recorder *Recorder) CallCount(method string) int {
+	return len(recorder.CallsTo(method))
+}
+
+// Fail makes every following call of the given method return the error, without calling its Func. A nil error stops
+// the method from failing.
+func (recorder *Recorder) Fail(method string, err error) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	if recorder.errors == nil {
+		recorder.errors = map[string]error{}
+	}
+
+	if err == nil {
+		delete(recorder.errors, method)
+
+		return
+	}
+
+	recorder.errors[method] = err
+}
+
+// Reset forgets every recorded call and injected error.
+func (recorder *Recorder) Reset() {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	recorder.calls = nil
+	recorder.errors = nil
+}
+
+// record records the call and returns the error injected for the method, if any.
+func (recorder *Recorder) record(ctx context.Context, method string, args ...interface{}) error {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	recorder.calls = append(recorder.calls, Call{Method: method, Context: ctx, Args: args})
+
+	return recorder.errors[method]
+}
+
+// Services holds a mock of every service.
+type Services struct {
+	VirtualMachine *VirtualMachineService
+	Snapshot       *SnapshotService
+	Preset         *PresetService
+	Template       *TemplateService
+	Site           *SiteService
+	Metadata       *MetadataService
+	SshKey         *SshKeyService
+}
+
+func NewServices() *Services {
+	return &Services{
+		VirtualMachine: &VirtualMachineService{},
+		Snapshot:       &SnapshotService{},
+		Preset:         &PresetService{},
+		Template:       &TemplateService{},
+		Site:           &SiteService{},
+		Metadata:       &MetadataService{},
+		SshKey:         &SshKeyService{},
+	}
+}
+
+// Client returns a Client using the mocks and binds the mocks to it. The given options are applied after the mocks
+// are installed, so they may replace individual services.
+func (services *Services) Client(options ...go_tilaa.Option) (*go_tilaa.Client, error) {
+	defaults := []go_tilaa.Option{
+		go_tilaa.WithVirtualMachineService(services.VirtualMachine),
+		go_tilaa.WithSnapshotService(services.Snapshot),
+		go_tilaa.WithPresetService(services.Preset),
+		go_tilaa.WithTemplateService(services.Template),
+		go_tilaa.WithSiteService(services.Site),
+		go_tilaa.WithMetadataService(services.Metadata),
+		go_tilaa.WithSshKeyService(services.SshKey),
+	}
+
+	client, err := go_tilaa.NewWithOptions(append(defaults, options...)...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	services.Bind(client)
+
+	return client, nil
+}
+
+// Bind makes the mocks bind every model they return to the given client.
+func (services *Services) Bind(client *go_tilaa.Client) {
+	services.VirtualMachine.Client = client
+	services.Snapshot.Client = client
+	services.Preset.Client = client
+	services.Template.Client = client
+	services.Site.Client = client
+	services.Metadata.Client = client
+	services.SshKey.Client = client
+}
+
+// Reset forgets the recorded calls and injected errors of every mock.
+func (services *Services) Reset() {
+	services.VirtualMachine.Reset()
+	services.Snapshot.Reset()
+	services.Preset.Reset()
+	services.Template.Reset()
+	services.Site.Reset()
+	services.Metadata.Reset()
+	services.SshKey.Reset()
+}
@@ -0,0 +1,152 @@
+package tilaamock
+
+import (
+	"context"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+)
+
+// SnapshotService mocks go_tilaa.SnapshotServiceInterface. Methods without a Func succeed, returning the given
+// snapshot or an empty result.
+type SnapshotService struct {
+	Recorder
+
+	// Client is bound to every returned snapshot and machine when set.
+	Client *go_tilaa.Client
+
+	ListFunc    func(context.Context) (*[]go_tilaa.Snapshot, error)
+	AddFunc     func(context.Context, *go_tilaa.VirtualMachine, string, bool, bool) (*go_tilaa.Snapshot, error)
+	ViewFunc    func(context.Context, int) (*go_tilaa.Snapshot, error)
+	RenameFunc  func(context.Context, *go_tilaa.Snapshot, string) (*go_tilaa.Snapshot, error)
+	DeleteFunc  func(context.Context, *go_tilaa.Snapshot) error
+	RestoreFunc func(context.Context, *go_tilaa.VirtualMachine, *go_tilaa.Snapshot) (*go_tilaa.VirtualMachine, error)
+}
+
+var _ go_tilaa.SnapshotServiceInterface = &SnapshotService{}
+
+func (mock *SnapshotService) List() (*[]go_tilaa.Snapshot, error) {
+	return mock.ListWithContext(context.Background())
+}
+
+func (mock *SnapshotService) ListWithContext(ctx context.Context) (*[]go_tilaa.Snapshot, error) {
+	if err := mock.record(ctx, "List"); err != nil {
+		return nil, err
+	}
+
+	if mock.ListFunc == nil {
+		return &[]go_tilaa.Snapshot{}, nil
+	}
+
+	snapshots, err := mock.ListFunc(ctx)
+
+	if snapshots != nil && mock.Client != nil {
+		for i := range *snapshots {
+			(*snapshots)[i].SetClient(mock.Client)
+		}
+	}
+
+	return snapshots, err
+}
+
+func (mock *SnapshotService) Add(machine *go_tilaa.VirtualMachine, name string, online bool, overwrite bool) (*go_tilaa.Snapshot, error) {
+	return mock.AddWithContext(context.Background(), machine, name, online, overwrite)
+}
+
+func (mock *SnapshotService) AddWithContext(ctx context.Context, machine *go_tilaa.VirtualMachine, name string, online bool, overwrite bool) (*go_tilaa.Snapshot, error) {
+	if err := mock.record(ctx, "Add", machine, name, online, overwrite); err != nil {
+		return go_tilaa.NewSnapshot(mock.Client), err
+	}
+
+	if mock.AddFunc == nil {
+		snapshot := go_tilaa.NewSnapshot(mock.Client)
+		snapshot.Name = name
+
+		return snapshot, nil
+	}
+
+	return mock.bind(mock.AddFunc(ctx, machine, name, online, overwrite))
+}
+
+func (mock *SnapshotService) View(snapshotId int) (*go_tilaa.Snapshot, error) {
+	return mock.ViewWithContext(context.Background(), snapshotId)
+}
+
+func (mock *SnapshotService) ViewWithContext(ctx context.Context, snapshotId int) (*go_tilaa.Snapshot, error) {
+	if err := mock.record(ctx, "View", snapshotId); err != nil {
+		return go_tilaa.NewSnapshot(mock.Client), err
+	}
+
+	if mock.ViewFunc == nil {
+		snapshot := go_tilaa.NewSnapshot(mock.Client)
+		snapshot.Id = snapshotId
+
+		return snapshot, nil
+	}
+
+	return mock.bind(mock.ViewFunc(ctx, snapshotId))
+}
+
+func (mock *SnapshotService) Rename(snapshot *go_tilaa.Snapshot, name string) (*go_tilaa.Snapshot, error) {
+	return mock.RenameWithContext(context.Background(), snapshot, name)
+}
+
+func (mock *SnapshotService) RenameWithContext(ctx context.Context, snapshot *go_tilaa.Snapshot, name string) (*go_tilaa.Snapshot, error) {
+	if err := mock.record(ctx, "Rename", snapshot, name); err != nil {
+		return snapshot, err
+	}
+
+	if mock.RenameFunc == nil {
+		snapshot.Name = name
+
+		return snapshot, nil
+	}
+
+	return mock.bind(mock.RenameFunc(ctx, snapshot, name))
+}
+
+func (mock *SnapshotService) Delete(snapshot *go_tilaa.Snapshot) error {
+	return mock.DeleteWithContext(context.Background(), snapshot)
+}
+
+func (mock *SnapshotService) DeleteWithContext(ctx context.Context, snapshot *go_tilaa.Snapshot) error {
+	if err := mock.record(ctx, "Delete", snapshot); err != nil {
+		return err
+	}
+
+	if mock.DeleteFunc == nil {
+		return nil
+	}
+
+	return mock.DeleteFunc(ctx, snapshot)
+}
+
+func (mock *SnapshotService) Restore(machine *go_tilaa.VirtualMachine, snapshot *go_tilaa.Snapshot) (*go_tilaa.VirtualMachine, error) {
+	return mock.RestoreWithContext(context.Background(), machine, snapshot)
+}
+
+func (mock *SnapshotService) RestoreWithContext(ctx context.Context, machine *go_tilaa.VirtualMachine, snapshot *go_tilaa.Snapshot) (*go_tilaa.VirtualMachine, error) {
+	if err := mock.record(ctx, "Restore", machine, snapshot); err != nil {
+		return machine, err
+	}
+
+	if mock.RestoreFunc == nil {
+		return machine, nil
+	}
+
+	machine, err := mock.RestoreFunc(ctx, machine, snapshot)
+
+	if machine != nil && mock.Client != nil {
+		machine.SetClient(mock.Client)
+	}
+
+	return machine, err
+}
+
+// bind binds the snapshot to the client of the mock and passes the results through.
+func (mock *SnapshotService) bind(snapshot *go_tilaa.Snapshot, err error) (*go_tilaa.Snapshot, error) {
+	if snapshot != nil && mock.Client != nil {
+		snapshot.SetClient(mock.Client)
+	}
+
+	return snapshot, err
+}
@@ -0,0 +1,124 @@
+package tilaamock
+
+import (
+	"context"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+)
+
+// SshKeyService mocks go_tilaa.SshKeyServiceInterface. Methods without a Func succeed, returning the given key or an
+// empty result.
+type SshKeyService struct {
+	Recorder
+
+	// Client is bound to every returned key when set.
+	Client *go_tilaa.Client
+
+	ListFunc   func(context.Context) (*[]go_tilaa.SshKey, error)
+	AddFunc    func(context.Context, *go_tilaa.SshKey) (*go_tilaa.SshKey, error)
+	ViewFunc   func(context.Context, int) (*go_tilaa.SshKey, error)
+	EditFunc   func(context.Context, *go_tilaa.SshKey) (*go_tilaa.SshKey, error)
+	DeleteFunc func(context.Context, *go_tilaa.SshKey) error
+}
+
+var _ go_tilaa.SshKeyServiceInterface = &SshKeyService{}
+
+func (mock *SshKeyService) List() (*[]go_tilaa.SshKey, error) {
+	return mock.ListWithContext(context.Background())
+}
+
+func (mock *SshKeyService) ListWithContext(ctx context.Context) (*[]go_tilaa.SshKey, error) {
+	if err := mock.record(ctx, "List"); err != nil {
+		return nil, err
+	}
+
+	if mock.ListFunc == nil {
+		return &[]go_tilaa.SshKey{}, nil
+	}
+
+	sshKeys, err := mock.ListFunc(ctx)
+
+	if sshKeys != nil && mock.Client != nil {
+		for i := range *sshKeys {
+			(*sshKeys)[i].SetClient(mock.Client)
+		}
+	}
+
+	return sshKeys, err
+}
+
+func (mock *SshKeyService) Add(sshKey *go_tilaa.SshKey) (*go_tilaa.SshKey, error) {
+	return mock.AddWithContext(context.Background(), sshKey)
+}
+
+func (mock *SshKeyService) AddWithContext(ctx context.Context, sshKey *go_tilaa.SshKey) (*go_tilaa.SshKey, error) {
+	if err := mock.record(ctx, "Add", sshKey); err != nil {
+		return go_tilaa.NewSshKey(mock.Client), err
+	}
+
+	if mock.AddFunc == nil {
+		return sshKey, nil
+	}
+
+	return mock.bind(mock.AddFunc(ctx, sshKey))
+}
+
+func (mock *SshKeyService) View(sshKeyId int) (*go_tilaa.SshKey, error) {
+	return mock.ViewWithContext(context.Background(), sshKeyId)
+}
+
+func (mock *SshKeyService) ViewWithContext(ctx context.Context, sshKeyId int) (*go_tilaa.SshKey, error) {
+	if err := mock.record(ctx, "View", sshKeyId); err != nil {
+		return go_tilaa.NewSshKey(mock.Client), err
+	}
+
+	if mock.ViewFunc == nil {
+		sshKey := go_tilaa.NewSshKey(mock.Client)
+		sshKey.Id = sshKeyId
+
+		return sshKey, nil
+	}
+
+	return mock.bind(mock.ViewFunc(ctx, sshKeyId))
+}
+
+func (mock *SshKeyService) Edit(sshKey *go_tilaa.SshKey) (*go_tilaa.SshKey, error) {
+	return mock.EditWithContext(context.Background(), sshKey)
+}
+
+func (mock *SshKeyService) EditWithContext(ctx context.Context, sshKey *go_tilaa.SshKey) (*go_tilaa.SshKey, error) {
+	if err := mock.record(ctx, "Edit", sshKey); err != nil {
+		return sshKey, err
+	}
+
+	if mock.EditFunc == nil {
+		return sshKey, nil
+	}
+
+	return mock.bind(mock.EditFunc(ctx, sshKey))
+}
+
+func (mock *SshKeyService) Delete(sshKey *go_tilaa.SshKey) error {
+	return mock.DeleteWithContext(context.Background(), sshKey)
+}
+
+func (mock *SshKeyService) DeleteWithContext(ctx context.Context, sshKey *go_tilaa.SshKey) error {
+	if err := mock.record(ctx, "Delete", sshKey); err != nil {
+		return err
+	}
+
+	if mock.DeleteFunc == nil {
+		return nil
+	}
+
+	return mock.DeleteFunc(ctx, sshKey)
+}
+
+// bind binds the key to the client of the mock and passes the results through.
+func (mock *SshKeyService) bind(sshKey *go_tilaa.SshKey, err error) (*go_tilaa.SshKey, error) {
+	if sshKey != nil && mock.Client != nil {
+		sshKey.SetClient(mock.Client)
+	}
+
+	return sshKey, err
+}
@@ -0,0 +1,255 @@
+package tilaamock
+
+import (
+	"context"
+	"time"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+)
+
+// VirtualMachineService mocks go_tilaa.VirtualMachineServiceInterface. Methods without a Func succeed, returning the
+// given machine or an empty result.
+type VirtualMachineService struct {
+	Recorder
+
+	// Client is bound to every returned machine and snapshot when set.
+	Client *go_tilaa.Client
+
+	ListFunc             func(context.Context) (*[]go_tilaa.VirtualMachine, error)
+	AddFunc              func(context.Context, *go_tilaa.VirtualMachine) (*go_tilaa.VirtualMachine, error)
+	AddFromSnapshotFunc  func(context.Context, *go_tilaa.VirtualMachine, *go_tilaa.Snapshot) (*go_tilaa.VirtualMachine, error)
+	ViewFunc             func(context.Context, int) (*go_tilaa.VirtualMachine, error)
+	EditFunc             func(context.Context, *go_tilaa.VirtualMachine) (*go_tilaa.VirtualMachine, error)
+	CancelFunc           func(context.Context, *go_tilaa.VirtualMachine, *time.Time) (*go_tilaa.VirtualMachine, error)
+	UndoCancellationFunc func(context.Context, *go_tilaa.VirtualMachine) error
+	GetCancelDatesFunc   func(context.Context, *go_tilaa.VirtualMachine) (*[]time.Time, error)
+	RunTaskFunc          func(context.Context, string, *go_tilaa.VirtualMachine) (*go_tilaa.VirtualMachine, error)
+	ReinstallFunc        func(context.Context, *go_tilaa.VirtualMachine) (*go_tilaa.VirtualMachine, error)
+	CreateSnapshotFunc   func(context.Context, *go_tilaa.VirtualMachine, string, bool, bool) (*go_tilaa.Snapshot, error)
+	RestoreSnapshotFunc  func(context.Context, *go_tilaa.VirtualMachine, *go_tilaa.Snapshot) (*go_tilaa.VirtualMachine, error)
+}
+
+var _ go_tilaa.VirtualMachineServiceInterface = &VirtualMachineService{}
+
+func (mock *VirtualMachineService) List() (*[]go_tilaa.VirtualMachine, error) {
+	return mock.ListWithContext(context.Background())
+}
+
+func (mock *VirtualMachineService) ListWithContext(ctx context.Context) (*[]go_tilaa.VirtualMachine, error) {
+	if err := mock.record(ctx, "List"); err != nil {
+		return nil, err
+	}
+
+	if mock.ListFunc == nil {
+		return &[]go_tilaa.VirtualMachine{}, nil
+	}
+
+	machines, err := mock.ListFunc(ctx)
+
+	if machines != nil && mock.Client != nil {
+		for i := range *machines {
+			(*machines)[i].SetClient(mock.Client)
+		}
+	}
+
+	return machines, err
+}
+
+func (mock *VirtualMachineService) Add(machine *go_tilaa.VirtualMachine) (*go_tilaa.VirtualMachine, error) {
+	return mock.AddWithContext(context.Background(), machine)
+}
+
+func (mock *VirtualMachineService) AddWithContext(ctx context.Context, machine *go_tilaa.VirtualMachine) (*go_tilaa.VirtualMachine, error) {
+	if err := mock.record(ctx, "Add", machine); err != nil {
+		return machine, err
+	}
+
+	if mock.AddFunc == nil {
+		return machine, nil
+	}
+
+	return mock.bind(mock.AddFunc(ctx, machine))
+}
+
+func (mock *VirtualMachineService) AddFromSnapshot(machine *go_tilaa.VirtualMachine, snapshot *go_tilaa.Snapshot) (*go_tilaa.VirtualMachine, error) {
+	return mock.AddFromSnapshotWithContext(context.Background(), machine, snapshot)
+}
+
+func (mock *VirtualMachineService) AddFromSnapshotWithContext(ctx context.Context, machine *go_tilaa.VirtualMachine, snapshot *go_tilaa.Snapshot) (*go_tilaa.VirtualMachine, error) {
+	if err := mock.record(ctx, "AddFromSnapshot", machine, snapshot); err != nil {
+		return machine, err
+	}
+
+	if mock.AddFromSnapshotFunc == nil {
+		return machine, nil
+	}
+
+	return mock.bind(mock.AddFromSnapshotFunc(ctx, machine, snapshot))
+}
+
+func (mock *VirtualMachineService) View(machineId int) (*go_tilaa.VirtualMachine, error) {
+	return mock.ViewWithContext(context.Background(), machineId)
+}
+
+func (mock *VirtualMachineService) ViewWithContext(ctx context.Context, machineId int) (*go_tilaa.VirtualMachine, error) {
+	if err := mock.record(ctx, "View", machineId); err != nil {
+		return go_tilaa.NewVirtualMachine(mock.Client), err
+	}
+
+	if mock.ViewFunc == nil {
+		machine := go_tilaa.NewVirtualMachine(mock.Client)
+		machine.Id = machineId
+
+		return machine, nil
+	}
+
+	return mock.bind(mock.ViewFunc(ctx, machineId))
+}
+
+func (mock *VirtualMachineService) Edit(machine *go_tilaa.VirtualMachine) (*go_tilaa.VirtualMachine, error) {
+	return mock.EditWithContext(context.Background(), machine)
+}
+
+func (mock *VirtualMachineService) EditWithContext(ctx context.Context, machine *go_tilaa.VirtualMachine) (*go_tilaa.VirtualMachine, error) {
+	if err := mock.record(ctx, "Edit", machine); err != nil {
+		return machine, err
+	}
+
+	if mock.EditFunc == nil {
+		return machine, nil
+	}
+
+	return mock.bind(mock.EditFunc(ctx, machine))
+}
+
+func (mock *VirtualMachineService) Cancel(machine *go_tilaa.VirtualMachine, date *time.Time) (*go_tilaa.VirtualMachine, error) {
+	return mock.CancelWithContext(context.Background(), machine, date)
+}
+
+func (mock *VirtualMachineService) CancelWithContext(ctx context.Context, machine *go_tilaa.VirtualMachine, date *time.Time) (*go_tilaa.VirtualMachine, error) {
+	if err := mock.record(ctx, "Cancel", machine, date); err != nil {
+		return machine, err
+	}
+
+	if mock.CancelFunc == nil {
+		machine.Cancelled = date
+
+		return machine, nil
+	}
+
+	return mock.bind(mock.CancelFunc(ctx, machine, date))
+}
+
+func (mock *VirtualMachineService) UndoCancellation(machine *go_tilaa.VirtualMachine) error {
+	return mock.UndoCancellationWithContext(context.Background(), machine)
+}
+
+func (mock *VirtualMachineService) UndoCancellationWithContext(ctx context.Context, machine *go_tilaa.VirtualMachine) error {
+	if err := mock.record(ctx, "UndoCancellation", machine); err != nil {
+		return err
+	}
+
+	if mock.UndoCancellationFunc == nil {
+		return nil
+	}
+
+	return mock.UndoCancellationFunc(ctx, machine)
+}
+
+func (mock *VirtualMachineService) GetCancelDates(machine *go_tilaa.VirtualMachine) (*[]time.Time, error) {
+	return mock.GetCancelDatesWithContext(context.Background(), machine)
+}
+
+func (mock *VirtualMachineService) GetCancelDatesWithContext(ctx context.Context, machine *go_tilaa.VirtualMachine) (*[]time.Time, error) {
+	if err := mock.record(ctx, "GetCancelDates", machine); err != nil {
+		return nil, err
+	}
+
+	if mock.GetCancelDatesFunc == nil {
+		return &[]time.Time{}, nil
+	}
+
+	return mock.GetCancelDatesFunc(ctx, machine)
+}
+
+func (mock *VirtualMachineService) RunTask(task string, machine *go_tilaa.VirtualMachine) (*go_tilaa.VirtualMachine, error) {
+	return mock.RunTaskWithContext(context.Background(), task, machine)
+}
+
+func (mock *VirtualMachineService) RunTaskWithContext(ctx context.Context, task string, machine *go_tilaa.VirtualMachine) (*go_tilaa.VirtualMachine, error) {
+	if err := mock.record(ctx, "RunTask", task, machine); err != nil {
+		return machine, err
+	}
+
+	if mock.RunTaskFunc == nil {
+		return machine, nil
+	}
+
+	return mock.bind(mock.RunTaskFunc(ctx, task, machine))
+}
+
+func (mock *VirtualMachineService) Reinstall(machine *go_tilaa.VirtualMachine) (*go_tilaa.VirtualMachine, error) {
+	return mock.ReinstallWithContext(context.Background(), machine)
+}
+
+func (mock *VirtualMachineService) ReinstallWithContext(ctx context.Context, machine *go_tilaa.VirtualMachine) (*go_tilaa.VirtualMachine, error) {
+	if err := mock.record(ctx, "Reinstall", machine); err != nil {
+		return machine, err
+	}
+
+	if mock.ReinstallFunc == nil {
+		return machine, nil
+	}
+
+	return mock.bind(mock.ReinstallFunc(ctx, machine))
+}
+
+func (mock *VirtualMachineService) CreateSnapshot(machine *go_tilaa.VirtualMachine, name string, online bool, overwrite bool) (*go_tilaa.Snapshot, error) {
+	return mock.CreateSnapshotWithContext(context.Background(), machine, name, online, overwrite)
+}
+
+func (mock *VirtualMachineService) CreateSnapshotWithContext(ctx context.Context, machine *go_tilaa.VirtualMachine, name string, online bool, overwrite bool) (*go_tilaa.Snapshot, error) {
+	if err := mock.record(ctx, "CreateSnapshot", machine, name, online, overwrite); err != nil {
+		return go_tilaa.NewSnapshot(mock.Client), err
+	}
+
+	if mock.CreateSnapshotFunc == nil {
+		snapshot := go_tilaa.NewSnapshot(mock.Client)
+		snapshot.Name = name
+
+		return snapshot, nil
+	}
+
+	snapshot, err := mock.CreateSnapshotFunc(ctx, machine, name, online, overwrite)
+
+	if snapshot != nil && mock.Client != nil {
+		snapshot.SetClient(mock.Client)
+	}
+
+	return snapshot, err
+}
+
+func (mock *VirtualMachineService) RestoreSnapshot(machine *go_tilaa.VirtualMachine, snapshot *go_tilaa.Snapshot) (*go_tilaa.VirtualMachine, error) {
+	return mock.RestoreSnapshotWithContext(context.Background(), machine, snapshot)
+}
+
+func (mock *VirtualMachineService) RestoreSnapshotWithContext(ctx context.Context, machine *go_tilaa.VirtualMachine, snapshot *go_tilaa.Snapshot) (*go_tilaa.VirtualMachine, error) {
+	if err := mock.record(ctx, "RestoreSnapshot", machine, snapshot); err != nil {
+		return machine, err
+	}
+
+	if mock.RestoreSnapshotFunc == nil {
+		return machine, nil
+	}
+
+	return mock.bind(mock.RestoreSnapshotFunc(ctx, machine, snapshot))
+}
+
+// bind binds the machine to the client of the mock and passes the results through.
+func (mock *VirtualMachineService) bind(machine *go_tilaa.VirtualMachine, err error) (*go_tilaa.VirtualMachine, error) {
+	if machine != nil && mock.Client != nil {
+		machine.SetClient(mock.Client)
+	}
+
+	return machine, err
+}
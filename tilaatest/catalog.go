@@ -0,0 +1,37 @@
+package tilaatest
+
+import (
+	"net/http"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+)
+
+func (server *Server) handlePresets(writer http.ResponseWriter, request *http.Request, segments []string) {
+	if len(segments) > 0 || request.Method != http.MethodGet {
+		writeMethodNotAllowed(writer)
+
+		return
+	}
+
+	writeJson(writer, go_tilaa.PresetsResponse{Status: go_tilaa.ResponseOk, Presets: server.presets})
+}
+
+func (server *Server) handleTemplates(writer http.ResponseWriter, request *http.Request, segments []string) {
+	if len(segments) > 0 || request.Method != http.MethodGet {
+		writeMethodNotAllowed(writer)
+
+		return
+	}
+
+	writeJson(writer, go_tilaa.TemplatesResponse{Status: go_tilaa.ResponseOk, Templates: server.templates})
+}
+
+func (server *Server) handleSites(writer http.ResponseWriter, request *http.Request, segments []string) {
+	if len(segments) > 0 || request.Method != http.MethodGet {
+		writeMethodNotAllowed(writer)
+
+		return
+	}
+
+	writeJson(writer, go_tilaa.SitesResponse{Status: go_tilaa.ResponseOk, Sites: server.sites})
+}
@@ -0,0 +1,117 @@
+package tilaatest
+
+import (
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+)
+
+// Fault is injected into the responses of an endpoint.
+type Fault struct {
+	// StatusCode is returned instead of handling the request, 0 only delays the request.
+	StatusCode int
+	Message    string
+
+	// Latency delays the response, or until the request is cancelled.
+	Latency time.Duration
+
+	// Times limits the number of requests the fault applies to, 0 applies it to every request.
+	Times int
+
+	// RetryAfter sets the Retry-After header of the error response.
+	RetryAfter time.Duration
+}
+
+type faultState struct {
+	method  string
+	pattern string
+	fault   Fault
+	applied int
+}
+
+// Inject adds a fault to the requests matching the method and path pattern. The pattern is matched using path.Match
+// against the path below the API version, e.g. "virtual_machines/*/start". An empty method matches every method.
+// Faults are applied in the order they were injected, only the first matching fault is applied to a request.
+func (server *Server) Inject(method string, pattern string, fault Fault) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	server.faults = append(server.faults, &faultState{method: method, pattern: pattern, fault: fault})
+}
+
+// ClearFaults removes every injected fault.
+func (server *Server) ClearFaults() {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	server.faults = nil
+}
+
+// applyFaults applies the first fault matching the request and reports whether the request should still be handled.
+func (server *Server) applyFaults(writer http.ResponseWriter, request *http.Request, requestPath string) bool {
+	fault, ok := server.matchFault(request.Method, requestPath)
+
+	if !ok {
+		return true
+	}
+
+	if fault.Latency > 0 {
+		timer := time.NewTimer(fault.Latency)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-request.Context().Done():
+			return false
+		}
+	}
+
+	if fault.StatusCode == 0 {
+		return true
+	}
+
+	if fault.RetryAfter > 0 {
+		writer.Header().Set("Retry-After", formatSeconds(fault.RetryAfter))
+	}
+
+	message := fault.Message
+
+	if message == "" {
+		message = http.StatusText(fault.StatusCode)
+	}
+
+	writeError(writer, fault.StatusCode, message)
+
+	return false
+}
+
+func (server *Server) matchFault(method string, requestPath string) (Fault, bool) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	for _, state := range server.faults {
+		if state.method != "" && state.method != method {
+			continue
+		}
+
+		if matched, _ := path.Match(state.pattern, requestPath); !matched {
+			continue
+		}
+
+		if state.fault.Times > 0 && state.applied >= state.fault.Times {
+			continue
+		}
+
+		state.applied++
+
+		return state.fault, true
+	}
+
+	return Fault{}, false
+}
+
+// formatSeconds formats the duration as whole seconds for the Retry-After header, rounding up.
+func formatSeconds(duration time.Duration) string {
+	return strconv.Itoa(int((duration + time.Second - 1) / time.Second))
+}
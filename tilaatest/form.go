@@ -0,0 +1,81 @@
+package tilaatest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// form reads the fields of a POST request and collects every problem with them.
+type form struct {
+	request *http.Request
+	errors  []string
+}
+
+func newForm(request *http.Request) *form {
+	return &form{request: request}
+}
+
+func (form *form) has(field string) bool {
+	_, ok := form.request.PostForm[field]
+
+	return ok
+}
+
+func (form *form) optional(field string, fallback string) string {
+	if !form.has(field) {
+		return fallback
+	}
+
+	return form.request.PostForm.Get(field)
+}
+
+func (form *form) required(field string) string {
+	value := strings.TrimSpace(form.request.PostForm.Get(field))
+
+	if value == "" {
+		form.fail(field, "is required")
+	}
+
+	return value
+}
+
+func (form *form) int(field string) int {
+	value := form.required(field)
+
+	if value == "" {
+		return 0
+	}
+
+	number, err := strconv.Atoi(value)
+
+	if err != nil {
+		form.fail(field, "%q is not a number", value)
+	}
+
+	return number
+}
+
+func (form *form) optionalInt(field string, fallback int) int {
+	if !form.has(field) {
+		return fallback
+	}
+
+	return form.int(field)
+}
+
+func (form *form) fail(field string, format string, args ...interface{}) {
+	form.errors = append(form.errors, field+": "+fmt.Sprintf(format, args...))
+}
+
+// writeErrors writes a bad request response listing every problem and reports whether there were any.
+func (form *form) writeErrors(writer http.ResponseWriter) bool {
+	if len(form.errors) == 0 {
+		return false
+	}
+
+	writeError(writer, http.StatusBadRequest, strings.Join(form.errors, "; "))
+
+	return true
+}
@@ -0,0 +1,89 @@
+package tilaatest
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+)
+
+func (server *Server) handleMetadata(writer http.ResponseWriter, request *http.Request, segments []string) {
+	if len(segments) == 0 {
+		switch request.Method {
+		case http.MethodGet:
+			metadata := make([]go_tilaa.Metadata, 0, len(server.metadata))
+
+			for _, entry := range server.metadata {
+				metadata = append(metadata, *entry)
+			}
+
+			sort.Slice(metadata, func(i, j int) bool {
+				return metadata[i].Id < metadata[j].Id
+			})
+
+			writeJson(writer, go_tilaa.MetadatasResponse{Status: go_tilaa.ResponseOk, Metadata: metadata})
+		case http.MethodPost:
+			server.createMetadata(writer, request)
+		default:
+			writeMethodNotAllowed(writer)
+		}
+
+		return
+	}
+
+	metadataId, err := strconv.Atoi(segments[0])
+	metadata, ok := server.metadata[metadataId]
+
+	if err != nil || !ok || len(segments) > 1 {
+		writeNotFound(writer)
+
+		return
+	}
+
+	switch request.Method {
+	case http.MethodGet:
+		writeJson(writer, go_tilaa.MetadataResponse{Status: go_tilaa.ResponseOk, Metadata: *metadata})
+	case http.MethodPost:
+		form := newForm(request)
+		name := form.required("name")
+
+		if form.writeErrors(writer) {
+			return
+		}
+
+		metadata.Name = name
+		metadata.UserData = request.PostForm.Get("user_data")
+		metadata.Modified = server.now()
+
+		writeOk(writer)
+	case http.MethodDelete:
+		delete(server.metadata, metadataId)
+
+		writeOk(writer)
+	default:
+		writeMethodNotAllowed(writer)
+	}
+}
+
+func (server *Server) createMetadata(writer http.ResponseWriter, request *http.Request) {
+	form := newForm(request)
+	name := form.required("name")
+
+	if form.writeErrors(writer) {
+		return
+	}
+
+	now := server.now()
+	metadataId := server.nextId()
+
+	server.metadata[metadataId] = &go_tilaa.Metadata{
+		Id:       metadataId,
+		Name:     name,
+		UserData: request.PostForm.Get("user_data"),
+		Created:  now,
+		Modified: now,
+	}
+
+	writeJson(writer, go_tilaa.NewMetadataResponse{Status: go_tilaa.ResponseOk, Id: metadataId})
+}
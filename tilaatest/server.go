@@ -0,0 +1,211 @@
+// Package tilaatest provides an in-process fake of the Tilaa v1 API for tests. A Server keeps virtual machines,
+// snapshots, SSH keys and metadata in memory, moves virtual machines and snapshots through their transitional statuses
+// over time, enforces basic auth and can inject errors and latency per endpoint.
+package tilaatest
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+)
+
+const (
+	DefaultUserName = "tilaa"
+	DefaultPassword = "tilaa"
+
+	DefaultTransitionDelay = 100 * time.Millisecond
+
+	// UserId is the ID of the account owning every resource on the server.
+	UserId = 1
+)
+
+// Option configures a Server created through NewServer.
+type Option func(*Server)
+
+// Server is a fake Tilaa API. It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	userName        string
+	password        string
+	transitionDelay time.Duration
+	now             func() time.Time
+
+	mutex sync.Mutex
+
+	lastId    int
+	machines  map[int]*machineState
+	snapshots map[int]*snapshotState
+	sshKeys   map[int]*go_tilaa.SshKey
+	metadata  map[int]*go_tilaa.Metadata
+
+	presets   go_tilaa.Presets
+	templates []go_tilaa.Template
+	sites     []go_tilaa.Site
+
+	faults []*faultState
+}
+
+// WithCredentials sets the username and password the server accepts, DefaultUserName and DefaultPassword otherwise.
+func WithCredentials(username string, password string) Option {
+	return func(server *Server) {
+		server.userName = username
+		server.password = password
+	}
+}
+
+// WithTransitionDelay sets how long virtual machines and snapshots stay in a transitional status, such as creating or
+// starting, before settling.
+func WithTransitionDelay(delay time.Duration) Option {
+	return func(server *Server) {
+		server.transitionDelay = delay
+	}
+}
+
+// WithClock replaces the clock used for status transitions and timestamps, allowing tests to control time.
+func WithClock(now func() time.Time) Option {
+	return func(server *Server) {
+		server.now = now
+	}
+}
+
+// NewServer starts a Server with a default catalog of presets, templates and sites. It should be closed when the test
+// is done.
+func NewServer(options ...Option) *Server {
+	server := &Server{
+		userName:        DefaultUserName,
+		password:        DefaultPassword,
+		transitionDelay: DefaultTransitionDelay,
+		now:             time.Now,
+		machines:        map[int]*machineState{},
+		snapshots:       map[int]*snapshotState{},
+		sshKeys:         map[int]*go_tilaa.SshKey{},
+		metadata:        map[int]*go_tilaa.Metadata{},
+		presets:         defaultPresets(),
+		templates:       defaultTemplates(),
+		sites:           defaultSites(),
+	}
+
+	for _, option := range options {
+		option(server)
+	}
+
+	server.Server = httptest.NewServer(http.HandlerFunc(server.serveHTTP))
+
+	return server
+}
+
+// Client returns a Client authenticated against the server. Retries are disabled so injected faults surface directly,
+// the given options are applied after the defaults and may override them.
+func (server *Server) Client(options ...go_tilaa.Option) (*go_tilaa.Client, error) {
+	defaults := []go_tilaa.Option{
+		go_tilaa.WithHttpClient(server.Server.Client()),
+		go_tilaa.WithBaseUrl(server.URL),
+		go_tilaa.WithBasicAuth(server.userName, server.password),
+		go_tilaa.WithRetryPolicy(go_tilaa.NoRetryPolicy),
+	}
+
+	return go_tilaa.NewWithOptions(append(defaults, options...)...)
+}
+
+func (server *Server) serveHTTP(writer http.ResponseWriter, request *http.Request) {
+	if !server.authorized(request) {
+		writeError(writer, http.StatusUnauthorized, "invalid credentials")
+
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(request.URL.Path, "/"+go_tilaa.ApiVersion), "/")
+
+	if !server.applyFaults(writer, request, path) {
+		return
+	}
+
+	if request.Method == http.MethodPost {
+		if err := request.ParseForm(); err != nil {
+			writeError(writer, http.StatusBadRequest, err.Error())
+
+			return
+		}
+	}
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	server.settle()
+
+	server.route(writer, request, strings.Split(path, "/"))
+}
+
+func (server *Server) authorized(request *http.Request) bool {
+	username, password, ok := request.BasicAuth()
+
+	if !ok {
+		return false
+	}
+
+	validUserName := subtle.ConstantTimeCompare([]byte(username), []byte(server.userName)) == 1
+	validPassword := subtle.ConstantTimeCompare([]byte(password), []byte(server.password)) == 1
+
+	return validUserName && validPassword
+}
+
+func (server *Server) route(writer http.ResponseWriter, request *http.Request, segments []string) {
+	handlers := map[string]func(http.ResponseWriter, *http.Request, []string){
+		"virtual_machines": server.handleVirtualMachines,
+		"snapshots":        server.handleSnapshots,
+		"ssh_keys":         server.handleSshKeys,
+		"metadata":         server.handleMetadata,
+		"presets":          server.handlePresets,
+		"templates":        server.handleTemplates,
+		"sites":            server.handleSites,
+	}
+
+	handler, ok := handlers[segments[0]]
+
+	if !ok {
+		writeError(writer, http.StatusNotFound, "unknown endpoint")
+
+		return
+	}
+
+	handler(writer, request, segments[1:])
+}
+
+func (server *Server) nextId() int {
+	server.lastId++
+
+	return server.lastId
+}
+
+func writeJson(writer http.ResponseWriter, response interface{}) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+
+	_ = json.NewEncoder(writer).Encode(response)
+}
+
+func writeOk(writer http.ResponseWriter) {
+	writeJson(writer, go_tilaa.StatusResponse{Status: go_tilaa.ResponseOk})
+}
+
+func writeError(writer http.ResponseWriter, statusCode int, message string) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(statusCode)
+
+	_ = json.NewEncoder(writer).Encode(go_tilaa.StatusResponse{Status: go_tilaa.ResponseError, Message: message})
+}
+
+func writeNotFound(writer http.ResponseWriter) {
+	writeError(writer, http.StatusNotFound, "not found")
+}
+
+func writeMethodNotAllowed(writer http.ResponseWriter) {
+	writeError(writer, http.StatusMethodNotAllowed, "method not allowed")
+}
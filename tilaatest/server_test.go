@@ -0,0 +1,266 @@
+package tilaatest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+	"github.com/pascal-splotches/go-tilaa/tilaatest"
+)
+
+// testClock is a clock which only moves when advanced.
+type testClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func (clock *testClock) Now() time.Time {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+
+	return clock.now
+}
+
+func (clock *testClock) Advance(duration time.Duration) {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+
+	clock.now = clock.now.Add(duration)
+}
+
+func newTestClient(t *testing.T, server *tilaatest.Server) *go_tilaa.Client {
+	client, err := server.Client()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+func statusCode(err error) int {
+	var responseError *go_tilaa.ApiResponseError
+
+	if errors.As(err, &responseError) {
+		return responseError.StatusCode
+	}
+
+	return 0
+}
+
+func TestServerRejectsInvalidCredentials(t *testing.T) {
+	server := tilaatest.NewServer(tilaatest.WithCredentials("alice", "secret"))
+	defer server.Close()
+
+	if _, err := newTestClient(t, server).Site.List(); err != nil {
+		t.Errorf("expected the configured credentials to be accepted, got %v", err)
+	}
+
+	for _, credentials := range []go_tilaa.Option{
+		go_tilaa.WithBasicAuth(tilaatest.DefaultUserName, tilaatest.DefaultPassword),
+		go_tilaa.WithBasicAuth("alice", "wrong"),
+	} {
+		client, err := server.Client(credentials)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := client.Site.List(); !errors.Is(err, go_tilaa.ErrUnauthorized) {
+			t.Errorf("expected ErrUnauthorized, got %v", err)
+		}
+	}
+
+	response, err := http.Get(server.URL + "/v1/sites")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = response.Body.Close()
+
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected a request without credentials to be rejected, got %d", response.StatusCode)
+	}
+}
+
+func TestServerFaultsAreCheckedAfterAuthentication(t *testing.T) {
+	server := tilaatest.NewServer()
+	defer server.Close()
+
+	server.Inject("", "*", tilaatest.Fault{StatusCode: http.StatusServiceUnavailable})
+
+	client, err := server.Client(go_tilaa.WithBasicAuth(tilaatest.DefaultUserName, "wrong"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.Site.List(); !errors.Is(err, go_tilaa.ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized before the fault, got %v", err)
+	}
+}
+
+func TestServerFaultMatching(t *testing.T) {
+	server := tilaatest.NewServer()
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	machineId := server.AddVirtualMachine(go_tilaa.VirtualMachine{Name: "web1"})
+
+	server.Inject(http.MethodGet, "virtual_machines/*", tilaatest.Fault{StatusCode: http.StatusBadGateway, Message: "upstream failed"})
+	server.Inject(http.MethodPost, "sites", tilaatest.Fault{StatusCode: http.StatusInternalServerError})
+
+	_, err := client.VirtualMachine.View(machineId)
+
+	if statusCode(err) != http.StatusBadGateway {
+		t.Errorf("expected the fault on the machine, got %v", err)
+	}
+
+	var responseError *go_tilaa.ApiResponseError
+
+	if errors.As(err, &responseError) && responseError.Message != "upstream failed" {
+		t.Errorf("expected the fault message, got %q", responseError.Message)
+	}
+
+	if _, err := client.VirtualMachine.List(); err != nil {
+		t.Errorf("expected the pattern not to match the listing, got %v", err)
+	}
+
+	if _, err := client.Site.List(); err != nil {
+		t.Errorf("expected the method not to match, got %v", err)
+	}
+
+	server.ClearFaults()
+
+	if _, err := client.VirtualMachine.View(machineId); err != nil {
+		t.Errorf("expected the cleared fault to be gone, got %v", err)
+	}
+}
+
+func TestServerFaultTimes(t *testing.T) {
+	server := tilaatest.NewServer()
+	defer server.Close()
+
+	client := newTestClient(t, server)
+
+	server.Inject("", "sites", tilaatest.Fault{StatusCode: http.StatusServiceUnavailable, Times: 2})
+	server.Inject("", "sites", tilaatest.Fault{StatusCode: http.StatusTooManyRequests, Times: 1})
+
+	for _, expected := range []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusTooManyRequests, 0} {
+		if _, err := client.Site.List(); statusCode(err) != expected {
+			t.Errorf("expected status %d, got %v", expected, err)
+		}
+	}
+}
+
+func TestServerFaultLatencyAndRetryAfter(t *testing.T) {
+	server := tilaatest.NewServer()
+	defer server.Close()
+
+	server.Inject("", "templates", tilaatest.Fault{Latency: 50 * time.Millisecond, Times: 1})
+	server.Inject("", "sites", tilaatest.Fault{StatusCode: http.StatusTooManyRequests, RetryAfter: 1500 * time.Millisecond})
+
+	started := time.Now()
+
+	if _, err := newTestClient(t, server).Template.List(); err != nil {
+		t.Errorf("expected a fault without a status to only delay the request, got %v", err)
+	}
+
+	if elapsed := time.Since(started); elapsed < 50*time.Millisecond {
+		t.Errorf("expected the request to be delayed by 50ms, took %s", elapsed)
+	}
+
+	request, err := http.NewRequest(http.MethodGet, server.URL+"/v1/sites", nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request.SetBasicAuth(tilaatest.DefaultUserName, tilaatest.DefaultPassword)
+
+	response, err := http.DefaultClient.Do(request)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = response.Body.Close()
+
+	if response.StatusCode != http.StatusTooManyRequests || response.Header.Get("Retry-After") != "2" {
+		t.Errorf("expected 429 with Retry-After rounded up to 2, got %d and %q", response.StatusCode, response.Header.Get("Retry-After"))
+	}
+}
+
+func TestServerLatencyFaultStopsOnCancel(t *testing.T) {
+	server := tilaatest.NewServer()
+	defer server.Close()
+
+	server.Inject("", "sites", tilaatest.Fault{Latency: time.Minute})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := newTestClient(t, server).Site.ListWithContext(ctx); err == nil {
+		t.Error("expected the cancelled request to fail")
+	}
+}
+
+func TestServerTransitions(t *testing.T) {
+	clock := &testClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	server := tilaatest.NewServer(tilaatest.WithClock(clock.Now), tilaatest.WithTransitionDelay(time.Minute))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	machineId := server.AddVirtualMachine(go_tilaa.VirtualMachine{Name: "web1"})
+	machine, err := client.VirtualMachine.View(machineId)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.VirtualMachine.RunTask(go_tilaa.TaskStop, machine); err != nil {
+		t.Fatal(err)
+	}
+
+	if machine, err = client.VirtualMachine.View(machineId); err != nil {
+		t.Fatal(err)
+	}
+
+	if machine.Status != go_tilaa.VirtualMachineStatusStopping {
+		t.Fatalf("expected the machine to be stopping, got %s", machine.Status)
+	}
+
+	if _, err := client.VirtualMachine.CreateSnapshot(machine, "during-stop", false, false); !errors.Is(err, go_tilaa.ErrConflict) {
+		t.Errorf("expected a snapshot during the transition to conflict, got %v", err)
+	}
+
+	clock.Advance(59 * time.Second)
+
+	if machine, err = client.VirtualMachine.View(machineId); err != nil {
+		t.Fatal(err)
+	}
+
+	if machine.Status != go_tilaa.VirtualMachineStatusStopping {
+		t.Fatalf("expected the machine to be stopping before the delay passed, got %s", machine.Status)
+	}
+
+	clock.Advance(time.Second)
+
+	if machine, err = client.VirtualMachine.View(machineId); err != nil {
+		t.Fatal(err)
+	}
+
+	if machine.Status != go_tilaa.VirtualMachineStatusStopped {
+		t.Fatalf("expected the machine to be stopped after the delay, got %s", machine.Status)
+	}
+
+	if _, err := client.VirtualMachine.CreateSnapshot(machine, "stopped", false, false); err != nil {
+		t.Errorf("expected a snapshot of the stopped machine to be created, got %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+package tilaatest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+)
+
+func (server *Server) handleSnapshots(writer http.ResponseWriter, request *http.Request, segments []string) {
+	if len(segments) == 0 {
+		if request.Method != http.MethodGet {
+			writeMethodNotAllowed(writer)
+
+			return
+		}
+
+		snapshots := make([]go_tilaa.Snapshot, 0, len(server.snapshots))
+
+		for _, state := range server.snapshots {
+			snapshots = append(snapshots, state.snapshot)
+		}
+
+		sort.Slice(snapshots, func(i, j int) bool {
+			return snapshots[i].Id < snapshots[j].Id
+		})
+
+		writeJson(writer, go_tilaa.SnapshotsResponse{Status: go_tilaa.ResponseOk, Snapshots: snapshots})
+
+		return
+	}
+
+	snapshotId, err := strconv.Atoi(segments[0])
+	state, ok := server.snapshots[snapshotId]
+
+	if err != nil || !ok || len(segments) > 1 {
+		writeNotFound(writer)
+
+		return
+	}
+
+	switch request.Method {
+	case http.MethodGet:
+		writeJson(writer, go_tilaa.SnapshotResponse{Status: go_tilaa.ResponseOk, Snapshot: state.snapshot})
+	case http.MethodPost:
+		form := newForm(request)
+		name := form.required("name")
+
+		if form.writeErrors(writer) {
+			return
+		}
+
+		state.snapshot.Name = name
+
+		writeOk(writer)
+	case http.MethodDelete:
+		delete(server.snapshots, snapshotId)
+
+		writeOk(writer)
+	default:
+		writeMethodNotAllowed(writer)
+	}
+}
+
+// createSnapshot creates a snapshot of the machine. Like the real API the response does not contain the ID of the
+// snapshot.
+func (server *Server) createSnapshot(writer http.ResponseWriter, request *http.Request, state *machineState) {
+	if request.Method != http.MethodPost {
+		writeMethodNotAllowed(writer)
+
+		return
+	}
+
+	if state.machine.Status.IsTransitional() {
+		writeError(writer, http.StatusConflict, fmt.Sprintf("virtual machine is %s", state.machine.Status))
+
+		return
+	}
+
+	form := newForm(request)
+	name := form.required("name")
+
+	if form.writeErrors(writer) {
+		return
+	}
+
+	for snapshotId, existing := range server.snapshots {
+		if existing.machineId != state.machine.Id || existing.snapshot.Name != name {
+			continue
+		}
+
+		if !form.has("overwrite") {
+			writeError(writer, http.StatusConflict, fmt.Sprintf("snapshot %q already exists", name))
+
+			return
+		}
+
+		delete(server.snapshots, snapshotId)
+	}
+
+	snapshot := &snapshotState{
+		snapshot: go_tilaa.Snapshot{
+			Id:       server.nextId(),
+			Name:     name,
+			Storage:  state.machine.Storage.Size,
+			Ram:      state.machine.Ram,
+			Template: state.machine.Template,
+			Status:   snapshotStatusCreating,
+			Created:  server.now(),
+		},
+		machineId: state.machine.Id,
+		pending:   &transition{status: string(snapshotStatusSuccess), at: server.now().Add(server.transitionDelay)},
+	}
+
+	server.snapshots[snapshot.snapshot.Id] = snapshot
+	server.transition(state, go_tilaa.VirtualMachineStatusSnapshotCreating, stableStatus(state.machine.Status))
+
+	writeOk(writer)
+}
+
+func (server *Server) restoreSnapshot(writer http.ResponseWriter, request *http.Request, state *machineState) {
+	if request.Method != http.MethodPost {
+		writeMethodNotAllowed(writer)
+
+		return
+	}
+
+	if state.machine.Status.IsTransitional() {
+		writeError(writer, http.StatusConflict, fmt.Sprintf("virtual machine is %s", state.machine.Status))
+
+		return
+	}
+
+	form := newForm(request)
+	snapshotId := form.int("snapshot")
+
+	if snapshot, ok := server.snapshots[snapshotId]; !ok || snapshot.snapshot.Status != snapshotStatusSuccess {
+		form.fail("snapshot", "%d is not a usable snapshot", snapshotId)
+	}
+
+	if form.writeErrors(writer) {
+		return
+	}
+
+	server.transition(state, go_tilaa.VirtualMachineStatusSnapshotRestoring, stableStatus(state.machine.Status))
+
+	writeOk(writer)
+}
@@ -0,0 +1,92 @@
+package tilaatest
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+)
+
+func (server *Server) handleSshKeys(writer http.ResponseWriter, request *http.Request, segments []string) {
+	if len(segments) == 0 {
+		switch request.Method {
+		case http.MethodGet:
+			sshKeys := make([]go_tilaa.SshKey, 0, len(server.sshKeys))
+
+			for _, sshKey := range server.sshKeys {
+				sshKeys = append(sshKeys, *sshKey)
+			}
+
+			sort.Slice(sshKeys, func(i, j int) bool {
+				return sshKeys[i].Id < sshKeys[j].Id
+			})
+
+			writeJson(writer, go_tilaa.SshKeysResponse{Status: go_tilaa.ResponseOk, SshKeys: sshKeys})
+		case http.MethodPost:
+			server.createSshKey(writer, request)
+		default:
+			writeMethodNotAllowed(writer)
+		}
+
+		return
+	}
+
+	sshKeyId, err := strconv.Atoi(segments[0])
+	sshKey, ok := server.sshKeys[sshKeyId]
+
+	if err != nil || !ok || len(segments) > 1 {
+		writeNotFound(writer)
+
+		return
+	}
+
+	switch request.Method {
+	case http.MethodGet:
+		writeJson(writer, go_tilaa.SshKeyResponse{Status: go_tilaa.ResponseOk, SshKey: *sshKey})
+	case http.MethodPost:
+		form := newForm(request)
+		label := form.required("label")
+		key := form.required("key")
+
+		if form.writeErrors(writer) {
+			return
+		}
+
+		sshKey.Label = label
+		sshKey.Key = key
+		sshKey.Modified = server.now()
+
+		writeOk(writer)
+	case http.MethodDelete:
+		delete(server.sshKeys, sshKeyId)
+
+		writeOk(writer)
+	default:
+		writeMethodNotAllowed(writer)
+	}
+}
+
+// createSshKey stores a new key. Like the real API the response does not contain the ID of the key.
+func (server *Server) createSshKey(writer http.ResponseWriter, request *http.Request) {
+	form := newForm(request)
+	label := form.required("label")
+	key := form.required("key")
+
+	if form.writeErrors(writer) {
+		return
+	}
+
+	now := server.now()
+
+	server.sshKeys[server.nextId()] = &go_tilaa.SshKey{
+		Id:       server.lastId,
+		UserId:   UserId,
+		Label:    label,
+		Key:      key,
+		Created:  now,
+		Modified: now,
+	}
+
+	writeOk(writer)
+}
@@ -0,0 +1,277 @@
+package tilaatest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+)
+
+const (
+	snapshotStatusCreating go_tilaa.SnapshotStatus = "creating"
+	snapshotStatusSuccess  go_tilaa.SnapshotStatus = go_tilaa.SnapshotStatusSuccess
+)
+
+// transition is a status a resource settles into once the given time has passed.
+type transition struct {
+	status string
+	at     time.Time
+}
+
+type machineState struct {
+	machine go_tilaa.VirtualMachine
+	pending *transition
+}
+
+type snapshotState struct {
+	snapshot  go_tilaa.Snapshot
+	machineId int
+	pending   *transition
+}
+
+// settle applies every transition which is due. The caller must hold the mutex.
+func (server *Server) settle() {
+	now := server.now()
+
+	for _, state := range server.machines {
+		if state.pending != nil && !now.Before(state.pending.at) {
+			state.machine.Status = go_tilaa.VirtualMachineStatus(state.pending.status)
+			state.pending = nil
+		}
+	}
+
+	for _, state := range server.snapshots {
+		if state.pending != nil && !now.Before(state.pending.at) {
+			state.snapshot.Status = go_tilaa.SnapshotStatus(state.pending.status)
+			state.pending = nil
+		}
+	}
+}
+
+// transition moves the machine into the transitional status, which settles into the final status after the
+// transition delay.
+func (server *Server) transition(state *machineState, transitional go_tilaa.VirtualMachineStatus, final go_tilaa.VirtualMachineStatus) {
+	state.machine.Status = transitional
+	state.pending = &transition{status: string(final), at: server.now().Add(server.transitionDelay)}
+}
+
+// AddVirtualMachine stores the machine as if it was created earlier and returns its ID. An ID is assigned when the
+// machine has none, the status defaults to running.
+func (server *Server) AddVirtualMachine(machine go_tilaa.VirtualMachine) int {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	if machine.Id == 0 {
+		machine.Id = server.nextId()
+	} else if machine.Id > server.lastId {
+		server.lastId = machine.Id
+	}
+
+	if machine.Status == "" {
+		machine.Status = go_tilaa.VirtualMachineStatusRunning
+	}
+
+	if machine.Created == nil {
+		created := server.now()
+		machine.Created = &created
+	}
+
+	server.machines[machine.Id] = &machineState{machine: machine}
+
+	return machine.Id
+}
+
+// VirtualMachine returns the current state of the machine with the given ID.
+func (server *Server) VirtualMachine(machineId int) (go_tilaa.VirtualMachine, bool) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	server.settle()
+
+	state, ok := server.machines[machineId]
+
+	if !ok {
+		return go_tilaa.VirtualMachine{}, false
+	}
+
+	return state.machine, true
+}
+
+// SetStatus forces the machine into the given status, cancelling any pending transition. This allows tests to
+// simulate failures such as create_failed.
+func (server *Server) SetStatus(machineId int, status go_tilaa.VirtualMachineStatus) bool {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	state, ok := server.machines[machineId]
+
+	if !ok {
+		return false
+	}
+
+	state.machine.Status = status
+	state.pending = nil
+
+	return true
+}
+
+// AddSnapshot stores the snapshot of the given machine and returns its ID. The status defaults to success.
+func (server *Server) AddSnapshot(machineId int, snapshot go_tilaa.Snapshot) int {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	if snapshot.Id == 0 {
+		snapshot.Id = server.nextId()
+	} else if snapshot.Id > server.lastId {
+		server.lastId = snapshot.Id
+	}
+
+	if snapshot.Status == "" {
+		snapshot.Status = snapshotStatusSuccess
+	}
+
+	if snapshot.Created.IsZero() {
+		snapshot.Created = server.now()
+	}
+
+	server.snapshots[snapshot.Id] = &snapshotState{snapshot: snapshot, machineId: machineId}
+
+	return snapshot.Id
+}
+
+// AddSshKey stores the key and returns its ID.
+func (server *Server) AddSshKey(sshKey go_tilaa.SshKey) int {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	if sshKey.Id == 0 {
+		sshKey.Id = server.nextId()
+	} else if sshKey.Id > server.lastId {
+		server.lastId = sshKey.Id
+	}
+
+	if sshKey.UserId == 0 {
+		sshKey.UserId = UserId
+	}
+
+	if sshKey.Created.IsZero() {
+		sshKey.Created = server.now()
+		sshKey.Modified = sshKey.Created
+	}
+
+	server.sshKeys[sshKey.Id] = &sshKey
+
+	return sshKey.Id
+}
+
+// AddMetadata stores the metadata and returns its ID.
+func (server *Server) AddMetadata(metadata go_tilaa.Metadata) int {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	if metadata.Id == 0 {
+		metadata.Id = server.nextId()
+	} else if metadata.Id > server.lastId {
+		server.lastId = metadata.Id
+	}
+
+	if metadata.Created.IsZero() {
+		metadata.Created = server.now()
+		metadata.Modified = metadata.Created
+	}
+
+	server.metadata[metadata.Id] = &metadata
+
+	return metadata.Id
+}
+
+// SetPresets replaces the RAM and storage sizes offered by the server.
+func (server *Server) SetPresets(presets go_tilaa.Presets) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	server.presets = presets
+}
+
+// SetTemplates replaces the templates offered by the server.
+func (server *Server) SetTemplates(templates []go_tilaa.Template) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	server.templates = append([]go_tilaa.Template{}, templates...)
+}
+
+// SetSites replaces the sites offered by the server.
+func (server *Server) SetSites(sites []go_tilaa.Site) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	server.sites = append([]go_tilaa.Site{}, sites...)
+}
+
+func (server *Server) findTemplate(templateId int) (go_tilaa.Template, bool) {
+	for _, template := range server.templates {
+		if template.Id == templateId {
+			return template, true
+		}
+	}
+
+	return go_tilaa.Template{}, false
+}
+
+func (server *Server) findSite(siteId int) (go_tilaa.Site, bool) {
+	for _, site := range server.sites {
+		if site.Id == siteId {
+			return site, true
+		}
+	}
+
+	return go_tilaa.Site{}, false
+}
+
+// network returns the network of a new machine, using an address from the documentation range 192.0.2.0/24.
+func network(machineId int, dnsName string) []go_tilaa.Network {
+	return []go_tilaa.Network{
+		{
+			Id:      machineId,
+			Family:  go_tilaa.NetworkFamilyIpv4,
+			Address: net.IPv4(192, 0, 2, byte(machineId%254+1)),
+			DnsName: dnsName,
+		},
+	}
+}
+
+func initialPassword(machineId int) string {
+	return fmt.Sprintf("password-%d", machineId)
+}
+
+func defaultPresets() go_tilaa.Presets {
+	var presets go_tilaa.Presets
+
+	_ = json.Unmarshal([]byte(`{
+		"ram": {"sizes": [1024, 2048, 4096, 8192, 16384]},
+		"storage": [
+			{"type": "ssd", "sizes": [10, 20, 40, 80, 160]},
+			{"type": "hdd", "sizes": [100, 250, 500]}
+		]
+	}`), &presets)
+
+	return presets
+}
+
+func defaultTemplates() []go_tilaa.Template {
+	return []go_tilaa.Template{
+		{Id: 1, Name: "Ubuntu 20.04", Ram: 1024, Storage: 10},
+		{Id: 2, Name: "Debian 10", Ram: 1024, Storage: 10},
+		{Id: 3, Name: "CentOS 8", Ram: 2048, Storage: 20},
+	}
+}
+
+func defaultSites() []go_tilaa.Site {
+	return []go_tilaa.Site{
+		{Id: 1, Name: "AMS1"},
+		{Id: 2, Name: "AMS2"},
+	}
+}
@@ -0,0 +1,373 @@
+package tilaatest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+)
+
+// taskTransitions lists the transitional and final status of every task.
+var taskTransitions = map[string][2]go_tilaa.VirtualMachineStatus{
+	go_tilaa.TaskStart:    {go_tilaa.VirtualMachineStatusStarting, go_tilaa.VirtualMachineStatusRunning},
+	go_tilaa.TaskStop:     {go_tilaa.VirtualMachineStatusStopping, go_tilaa.VirtualMachineStatusStopped},
+	go_tilaa.TaskRestart:  {go_tilaa.VirtualMachineStatusRestarting, go_tilaa.VirtualMachineStatusRunning},
+	go_tilaa.TaskPowerOff: {go_tilaa.VirtualMachineStatusStopping, go_tilaa.VirtualMachineStatusStopped},
+	go_tilaa.TaskRescue:   {go_tilaa.VirtualMachineStatusRestarting, go_tilaa.VirtualMachineStatusRunning_Rescue},
+}
+
+func (server *Server) handleVirtualMachines(writer http.ResponseWriter, request *http.Request, segments []string) {
+	if len(segments) == 0 {
+		switch request.Method {
+		case http.MethodGet:
+			server.listVirtualMachines(writer)
+		case http.MethodPost:
+			server.createVirtualMachine(writer, request)
+		default:
+			writeMethodNotAllowed(writer)
+		}
+
+		return
+	}
+
+	machineId, err := strconv.Atoi(segments[0])
+
+	if err != nil {
+		writeNotFound(writer)
+
+		return
+	}
+
+	state, ok := server.machines[machineId]
+
+	if !ok {
+		writeNotFound(writer)
+
+		return
+	}
+
+	if len(segments) == 1 {
+		switch request.Method {
+		case http.MethodGet:
+			writeJson(writer, go_tilaa.VirtualMachineResponse{Status: go_tilaa.ResponseOk, VirtualMachine: state.machine})
+		case http.MethodPost:
+			server.editVirtualMachine(writer, request, state)
+		default:
+			writeMethodNotAllowed(writer)
+		}
+
+		return
+	}
+
+	if len(segments) > 2 {
+		writeNotFound(writer)
+
+		return
+	}
+
+	switch segments[1] {
+	case "cancel":
+		server.cancelVirtualMachine(writer, request, state)
+	case "create_snapshot":
+		server.createSnapshot(writer, request, state)
+	case "restore_snapshot":
+		server.restoreSnapshot(writer, request, state)
+	default:
+		server.runTask(writer, request, state, segments[1])
+	}
+}
+
+func (server *Server) listVirtualMachines(writer http.ResponseWriter) {
+	machines := make([]go_tilaa.VirtualMachine, 0, len(server.machines))
+
+	for _, state := range server.machines {
+		machines = append(machines, state.machine)
+	}
+
+	sort.Slice(machines, func(i, j int) bool {
+		return machines[i].Id < machines[j].Id
+	})
+
+	writeJson(writer, go_tilaa.VirtualMachinesResponse{Status: go_tilaa.ResponseOk, VirtualMachines: machines})
+}
+
+func (server *Server) createVirtualMachine(writer http.ResponseWriter, request *http.Request) {
+	form := newForm(request)
+
+	name := form.required("name")
+	ram := form.int("ram")
+	storage := form.int("storage")
+	storageType := go_tilaa.StorageType(form.optional("storage_type", string(go_tilaa.StorageTypeSsd)))
+	templateId := form.int("template")
+	siteId := form.int("site")
+	cpuCores := form.int("cpu_count")
+	cpuCap := form.optionalInt("cpu_cap", cpuCores*go_tilaa.MaxCpuCapPerCore)
+	metadataId := form.optionalInt("metadata", 0)
+	snapshotId := form.optionalInt("snapshot", 0)
+
+	sshKeyIds := make([]go_tilaa.ResourceId, 0, len(request.PostForm["ssh_keys[]"]))
+
+	for _, value := range request.PostForm["ssh_keys[]"] {
+		sshKeyId, err := strconv.Atoi(value)
+
+		if _, ok := server.sshKeys[sshKeyId]; err != nil || !ok {
+			form.fail("ssh_keys", "%q is not an SSH key", value)
+
+			continue
+		}
+
+		sshKeyIds = append(sshKeyIds, go_tilaa.ResourceId(sshKeyId))
+	}
+
+	template, ok := server.findTemplate(templateId)
+
+	if !ok {
+		form.fail("template", "%d is not an available template", templateId)
+	}
+
+	site, ok := server.findSite(siteId)
+
+	if !ok {
+		form.fail("site", "%d is not an available site", siteId)
+	}
+
+	if !server.presets.ValidRam(ram) {
+		form.fail("ram", "%d is not an available size", ram)
+	}
+
+	if !server.presets.ValidStorage(storageType, storage) {
+		form.fail("storage", "%d is not an available %s size", storage, storageType)
+	}
+
+	server.validateCpu(form, cpuCores, cpuCap)
+
+	if _, ok := server.metadata[metadataId]; metadataId != 0 && !ok {
+		form.fail("metadata", "%d is not a metadata entry", metadataId)
+	}
+
+	if state, ok := server.snapshots[snapshotId]; snapshotId != 0 && (!ok || state.snapshot.Status != snapshotStatusSuccess) {
+		form.fail("snapshot", "%d is not a usable snapshot", snapshotId)
+	}
+
+	if form.writeErrors(writer) {
+		return
+	}
+
+	machineId := server.nextId()
+	created := server.now()
+
+	state := &machineState{
+		machine: go_tilaa.VirtualMachine{
+			Id:       machineId,
+			Name:     name,
+			Cpu:      go_tilaa.Cpu{Cores: cpuCores, Cap: cpuCap},
+			Ram:      ram,
+			Storage:  go_tilaa.Storage{Size: storage, Type: storageType},
+			Site:     site,
+			Template: template,
+			Network:  network(machineId, form.optional("dns_name", "")),
+			SshKeys:  sshKeyIds,
+			Metadata: go_tilaa.ResourceId(metadataId),
+			Admin:    go_tilaa.Admin{Account: "root", InitialPassword: initialPassword(machineId)},
+			Created:  &created,
+		},
+	}
+
+	server.transition(state, go_tilaa.VirtualMachineStatusCreating, go_tilaa.VirtualMachineStatusRunning)
+	server.machines[machineId] = state
+
+	writeJson(writer, go_tilaa.NewVirtualMachineResponse{Status: go_tilaa.ResponseOk, Id: machineId})
+}
+
+func (server *Server) editVirtualMachine(writer http.ResponseWriter, request *http.Request, state *machineState) {
+	if state.machine.Status.IsTransitional() {
+		writeError(writer, http.StatusConflict, fmt.Sprintf("virtual machine is %s", state.machine.Status))
+
+		return
+	}
+
+	form := newForm(request)
+
+	if form.has("reinstall") {
+		server.reinstallVirtualMachine(writer, form, state)
+
+		return
+	}
+
+	machine := state.machine
+
+	if form.has("name") {
+		machine.Name = form.required("name")
+	}
+
+	if form.has("ram") {
+		machine.Ram = form.int("ram")
+
+		if !server.presets.ValidRam(machine.Ram) {
+			form.fail("ram", "%d is not an available size", machine.Ram)
+		}
+	}
+
+	if form.has("storage") {
+		machine.Storage.Size = form.int("storage")
+
+		if !server.presets.ValidStorage(machine.Storage.Type, machine.Storage.Size) {
+			form.fail("storage", "%d is not an available %s size", machine.Storage.Size, machine.Storage.Type)
+		}
+
+		if machine.Storage.Size < state.machine.Storage.Size && !form.has("confirm_reinstall") {
+			form.fail("storage", "shrinking storage requires confirm_reinstall")
+		}
+	}
+
+	if form.has("cpu_count") {
+		machine.Cpu.Cores = form.int("cpu_count")
+	}
+
+	if form.has("cpu_cap") {
+		machine.Cpu.Cap = form.int("cpu_cap")
+	}
+
+	server.validateCpu(form, machine.Cpu.Cores, machine.Cpu.Cap)
+
+	if form.writeErrors(writer) {
+		return
+	}
+
+	resized := machine.Ram != state.machine.Ram || machine.Storage != state.machine.Storage || machine.Cpu != state.machine.Cpu
+
+	state.machine = machine
+
+	if resized {
+		server.transition(state, go_tilaa.VirtualMachineStatusResizing, stableStatus(machine.Status))
+	}
+
+	writeOk(writer)
+}
+
+func (server *Server) reinstallVirtualMachine(writer http.ResponseWriter, form *form, state *machineState) {
+	templateId := form.int("template")
+	template, ok := server.findTemplate(templateId)
+
+	if !ok {
+		form.fail("template", "%d is not an available template", templateId)
+	}
+
+	if !form.has("confirm_reinstall") {
+		form.fail("confirm_reinstall", "reinstalling requires confirmation")
+	}
+
+	if form.writeErrors(writer) {
+		return
+	}
+
+	state.machine.Template = template
+	state.machine.Admin.InitialPassword = initialPassword(state.machine.Id)
+
+	server.transition(state, go_tilaa.VirtualMachineStatusCreating, go_tilaa.VirtualMachineStatusRunning)
+
+	writeOk(writer)
+}
+
+func (server *Server) cancelVirtualMachine(writer http.ResponseWriter, request *http.Request, state *machineState) {
+	dates := server.cancelDates()
+
+	switch request.Method {
+	case http.MethodGet:
+		writeJson(writer, go_tilaa.CancelDatesResponse{Status: go_tilaa.ResponseOk, CancelDates: dates})
+	case http.MethodPost:
+		value := request.PostForm.Get("date")
+
+		if value == "0" {
+			state.machine.Cancelled = nil
+
+			writeOk(writer)
+
+			return
+		}
+
+		date, err := time.Parse("2006-01-02", value)
+
+		if err != nil || !containsDate(dates, date) {
+			writeError(writer, http.StatusBadRequest, fmt.Sprintf("date: %q is not a valid cancel date", value))
+
+			return
+		}
+
+		state.machine.Cancelled = &date
+
+		writeOk(writer)
+	default:
+		writeMethodNotAllowed(writer)
+	}
+}
+
+// cancelDates returns the last day of the current and the next two months.
+func (server *Server) cancelDates() []time.Time {
+	now := server.now().UTC()
+	dates := make([]time.Time, 3)
+
+	for i := range dates {
+		dates[i] = time.Date(now.Year(), now.Month()+time.Month(i)+1, 0, 0, 0, 0, 0, time.UTC)
+	}
+
+	return dates
+}
+
+func (server *Server) runTask(writer http.ResponseWriter, request *http.Request, state *machineState, task string) {
+	statuses, ok := taskTransitions[task]
+
+	if !ok {
+		writeNotFound(writer)
+
+		return
+	}
+
+	if request.Method != http.MethodGet {
+		writeMethodNotAllowed(writer)
+
+		return
+	}
+
+	if !state.machine.Status.CanRunTask(task) {
+		writeError(writer, http.StatusConflict, fmt.Sprintf("task %s is not allowed while the virtual machine is %s", task, state.machine.Status))
+
+		return
+	}
+
+	server.transition(state, statuses[0], statuses[1])
+
+	writeOk(writer)
+}
+
+func (server *Server) validateCpu(form *form, cores int, cpuCap int) {
+	if cores < go_tilaa.MinCpuCores || cores > go_tilaa.MaxCpuCores {
+		form.fail("cpu_count", "%d is not between %d and %d", cores, go_tilaa.MinCpuCores, go_tilaa.MaxCpuCores)
+	}
+
+	if cpuCap < go_tilaa.MinCpuCap || cpuCap > cores*go_tilaa.MaxCpuCapPerCore {
+		form.fail("cpu_cap", "%d is not between %d and %d", cpuCap, go_tilaa.MinCpuCap, cores*go_tilaa.MaxCpuCapPerCore)
+	}
+}
+
+// stableStatus returns the status a machine returns to after an operation which does not change its power state.
+func stableStatus(status go_tilaa.VirtualMachineStatus) go_tilaa.VirtualMachineStatus {
+	if status == go_tilaa.VirtualMachineStatusStopped {
+		return go_tilaa.VirtualMachineStatusStopped
+	}
+
+	return go_tilaa.VirtualMachineStatusRunning
+}
+
+func containsDate(dates []time.Time, date time.Time) bool {
+	for _, candidate := range dates {
+		if candidate.Equal(date) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,413 @@
+package go_tilaa
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// MaxUserDataSize is the largest user data accepted, the limit commonly imposed by metadata services.
+const MaxUserDataSize = 16 * 1024
+
+const (
+	UserDataContentTypeCloudConfig = "text/cloud-config"
+	UserDataContentTypeShellScript = "text/x-shellscript"
+	UserDataContentTypeBoothook    = "text/cloud-boothook"
+	UserDataContentTypeInclude     = "text/x-include-url"
+	UserDataContentTypeJinja       = "text/jinja2"
+	UserDataContentTypeMultipart   = "multipart/mixed"
+	UserDataContentTypeGzip        = "application/x-gzip"
+)
+
+// gzipMagic starts gzip compressed user data, which cloud-init decompresses before detecting its content type.
+const gzipMagic = "\x1f\x8b"
+
+// maxDecompressedUserDataSize bounds how much of gzip compressed user data is decompressed to validate it.
+const maxDecompressedUserDataSize = 1024 * 1024
+
+// userDataHeaders maps the first line of user data to its content type, as done by cloud-init. More specific prefixes
+// come first.
+var userDataHeaders = []struct {
+	prefix      string
+	contentType string
+}{
+	{"#cloud-config-archive", "text/cloud-config-archive"},
+	{"#cloud-config-jsonp", "text/cloud-config-jsonp"},
+	{CloudConfigHeader, UserDataContentTypeCloudConfig},
+	{"#cloud-boothook", UserDataContentTypeBoothook},
+	{"#include-once", "text/x-include-once-url"},
+	{"#include", UserDataContentTypeInclude},
+	{"#part-handler", "text/part-handler"},
+	{"#upstart-job", "text/upstart-job"},
+	{"#!", UserDataContentTypeShellScript},
+	{"## template: jinja", UserDataContentTypeJinja},
+	{"Content-Type: multipart/", UserDataContentTypeMultipart},
+}
+
+// cloudConfigKeys lists the top level keys understood by the cloud-init modules, unknown keys are most likely typos.
+var cloudConfigKeys = toSet(
+	"allow_public_ssh_keys", "ansible", "apk_repos", "apt", "apt_pipelining", "apt_preserve_sources_list",
+	"apt_update", "apt_upgrade", "autoinstall", "bootcmd", "byobu_by_default", "ca-certs", "ca_certs", "chef",
+	"chpasswd", "cloud_config_modules", "cloud_final_modules", "cloud_init_modules", "datasource",
+	"datasource_list", "device_aliases", "disable_ec2_metadata", "disable_root", "disable_root_opts", "disk_setup",
+	"drivers", "fan", "final_message", "fqdn", "fs_setup", "groups", "growpart", "hostname", "keyboard",
+	"landscape", "locale", "locale_configfile", "lxd", "manage_etc_hosts", "manage_resolv_conf", "mcollective",
+	"merge_how", "merge_type", "mount_default_fields", "mounts", "network", "no_ssh_fingerprints", "ntp", "output",
+	"package_reboot_if_required", "package_update", "package_upgrade", "packages", "password", "phone_home",
+	"power_state", "prefer_fqdn_over_hostname", "preserve_hostname", "puppet", "random_seed", "reporting",
+	"resize_rootfs", "resolv_conf", "rh_subscription", "rsyslog", "runcmd", "salt_minion", "snap", "spacewalk",
+	"ssh", "ssh_authorized_keys", "ssh_deletekeys", "ssh_fp_console_blacklist", "ssh_genkeytypes",
+	"ssh_import_id", "ssh_key_console_blacklist", "ssh_keys", "ssh_publish_hostkeys", "ssh_pwauth",
+	"ssh_quiet_keygen", "ssh_redirect_user", "swap", "syslog_fix_perms", "system_info", "timezone",
+	"ubuntu_advantage", "ubuntu_pro", "updates", "user", "users", "vendor_data", "wireguard", "write_files",
+	"yum_repos", "zypper",
+)
+
+// UserDataPart is a single part of multipart user data. The content type is detected from the first line of the
+// content when it is empty.
+type UserDataPart struct {
+	ContentType string
+	Filename    string
+	Content     string
+}
+
+// ValidateUserData checks user data strictly: its size, that it starts with a header recognised by cloud-init, and for
+// cloud-config that it is a YAML mapping of known keys with well formed users, keys, files and commands. Parts of
+// multipart and gzip compressed user data are checked individually. It returns a ValidationError listing every problem
+// found.
+//
+// Metadata.Validate only rejects user data which is too large or cloud-config which is not valid YAML, as cloud-init
+// accepts more than is checked here.
+func ValidateUserData(userData string) error {
+	validationError := NewValidationError()
+
+	validateUserData(validationError, "user_data", userData, true)
+
+	if validationError.HasErrors() {
+		return validationError
+	}
+
+	return nil
+}
+
+// validateUserData checks the size and the content of user data. Unless strict is set only problems which would
+// certainly break cloud-init are reported, such as invalid YAML.
+func validateUserData(validationError *ValidationError, field string, userData string, strict bool) {
+	if len(userData) > MaxUserDataSize {
+		validationError.Add(field, "is %d bytes, the limit is %d bytes", len(userData), MaxUserDataSize)
+	}
+
+	validateUserDataContent(validationError, field, userData, strict)
+}
+
+func validateUserDataContent(validationError *ValidationError, field string, userData string, strict bool) {
+	if strings.TrimSpace(userData) == "" {
+		return
+	}
+
+	contentType := UserDataContentType(userData)
+
+	switch contentType {
+	case "":
+		if strict {
+			validationError.Add(field, "must start with %s, #! or another header recognised by cloud-init", CloudConfigHeader)
+		}
+	case UserDataContentTypeCloudConfig:
+		validateCloudConfig(validationError, field, userData, strict)
+	case UserDataContentTypeMultipart:
+		validateMultipart(validationError, field, userData, strict)
+	case UserDataContentTypeGzip:
+		validateGzip(validationError, field, userData, strict)
+	}
+}
+
+// UserDataContentType detects the content type of user data from its first line, returning an empty string when it
+// is not recognised.
+func UserDataContentType(userData string) string {
+	if strings.HasPrefix(userData, gzipMagic) {
+		return UserDataContentTypeGzip
+	}
+
+	firstLine := strings.SplitN(strings.TrimLeft(userData, " \t\r\n"), "\n", 2)[0]
+
+	for _, header := range userDataHeaders {
+		if strings.HasPrefix(firstLine, header.prefix) {
+			return header.contentType
+		}
+	}
+
+	return ""
+}
+
+// validateCloudConfig parses the YAML of cloud-config and, when strict is set, checks the keys modelled by CloudConfig.
+// Values are decoded generically, as cloud-init accepts several forms for most keys.
+func validateCloudConfig(validationError *ValidationError, field string, userData string, strict bool) {
+	var config map[string]interface{}
+
+	if err := yaml.Unmarshal([]byte(userData), &config); err != nil {
+		validationError.Add(field, "invalid YAML: %s", strings.TrimPrefix(err.Error(), "yaml: "))
+
+		return
+	}
+
+	if !strict {
+		return
+	}
+
+	keys := make([]string, 0, len(config))
+
+	for key := range config {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if !cloudConfigKeys[key] {
+			validationError.Add(field, "unknown cloud-config key %q", key)
+		}
+	}
+
+	for _, key := range []string{"packages", "runcmd", "bootcmd", "write_files", "ssh_authorized_keys"} {
+		if value, ok := config[key]; ok {
+			if _, ok := value.([]interface{}); !ok && value != nil {
+				validationError.Add(field+"."+key, "must be a list")
+			}
+		}
+	}
+
+	for _, key := range []string{"runcmd", "bootcmd"} {
+		commands, _ := config[key].([]interface{})
+
+		for i, command := range commands {
+			switch command.(type) {
+			case string, []interface{}:
+			default:
+				validationError.Add(fmt.Sprintf("%s.%s[%d]", field, key, i), "must be a string or a list of arguments")
+			}
+		}
+	}
+
+	files, _ := config["write_files"].([]interface{})
+
+	for i, file := range files {
+		entry, _ := file.(map[interface{}]interface{})
+		filePath, _ := entry["path"].(string)
+
+		if filePath == "" {
+			validationError.Add(fmt.Sprintf("%s.write_files[%d].path", field, i), "can not be empty")
+		}
+	}
+
+	sshKeys, _ := config["ssh_authorized_keys"].([]interface{})
+
+	validateAuthorizedKeys(validationError, field+".ssh_authorized_keys", toStrings(sshKeys))
+
+	switch users := config["users"].(type) {
+	case nil, string:
+	case []interface{}:
+		for i, user := range users {
+			switch entry := user.(type) {
+			case string:
+			case map[interface{}]interface{}:
+				if name, _ := entry["name"].(string); name == "" {
+					validationError.Add(fmt.Sprintf("%s.users[%d].name", field, i), "can not be empty")
+				}
+
+				userKeys, _ := entry["ssh_authorized_keys"].([]interface{})
+
+				validateAuthorizedKeys(validationError, fmt.Sprintf("%s.users[%d].ssh_authorized_keys", field, i), toStrings(userKeys))
+			default:
+				validationError.Add(fmt.Sprintf("%s.users[%d]", field, i), "must be a name or a mapping")
+			}
+		}
+	default:
+		validationError.Add(field+".users", "must be a list")
+	}
+}
+
+// validateMultipart checks every part of MIME multipart user data. Malformed messages are only reported when strict is
+// set.
+func validateMultipart(validationError *ValidationError, field string, userData string, strict bool) {
+	header, body := splitMimeHeader(userData)
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] == "" {
+		if strict {
+			validationError.Add(field, "invalid multipart Content-Type")
+		}
+
+		return
+	}
+
+	reader := multipart.NewReader(strings.NewReader(body), params["boundary"])
+
+	for i := 0; ; i++ {
+		part, err := reader.NextPart()
+
+		if err != nil {
+			if err != io.EOF && strict {
+				validationError.Add(field, "invalid multipart message: %s", err.Error())
+			}
+
+			return
+		}
+
+		content, err := ioutil.ReadAll(part)
+
+		if err != nil {
+			if strict {
+				validationError.Add(field, "invalid multipart message: %s", err.Error())
+			}
+
+			return
+		}
+
+		partField := fmt.Sprintf("%s.part[%d]", field, i)
+		partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+
+		// Parts may omit the header line, their content type is given by the part header instead
+		if partType == UserDataContentTypeCloudConfig {
+			validateCloudConfig(validationError, partField, string(content), strict)
+		} else if partType == "" || partType == "text/plain" {
+			validateUserDataContent(validationError, partField, string(content), strict)
+		}
+	}
+}
+
+// validateGzip decompresses gzip compressed user data and checks its content. The size limit applies to the compressed
+// user data only.
+func validateGzip(validationError *ValidationError, field string, userData string, strict bool) {
+	reader, err := gzip.NewReader(strings.NewReader(userData))
+
+	if err != nil {
+		if strict {
+			validationError.Add(field, "invalid gzip data: %s", err.Error())
+		}
+
+		return
+	}
+
+	content, err := ioutil.ReadAll(io.LimitReader(reader, maxDecompressedUserDataSize))
+
+	if err != nil {
+		if strict {
+			validationError.Add(field, "invalid gzip data: %s", err.Error())
+		}
+
+		return
+	}
+
+	validateUserDataContent(validationError, field, string(content), strict)
+}
+
+// splitMimeHeader splits the leading MIME headers of a message from its body.
+func splitMimeHeader(message string) (textproto.MIMEHeader, string) {
+	message = strings.Replace(message, "\r\n", "\n", -1)
+	parts := strings.SplitN(message, "\n\n", 2)
+	header := textproto.MIMEHeader{}
+
+	for _, line := range strings.Split(parts[0], "\n") {
+		if index := strings.Index(line, ":"); index > 0 {
+			header.Add(strings.TrimSpace(line[:index]), strings.TrimSpace(line[index+1:]))
+		}
+	}
+
+	if len(parts) < 2 {
+		return header, ""
+	}
+
+	return header, parts[1]
+}
+
+// NewMultipartUserData combines scripts and configs into MIME multipart user data, which cloud-init processes part by
+// part. The boundary is derived from the content, so the same parts always produce the same user data.
+func NewMultipartUserData(parts ...UserDataPart) (string, error) {
+	hash := sha256.New()
+
+	for _, part := range parts {
+		hash.Write([]byte(part.ContentType + "\x00" + part.Filename + "\x00" + part.Content + "\x00"))
+	}
+
+	var body bytes.Buffer
+
+	writer := multipart.NewWriter(&body)
+
+	if err := writer.SetBoundary("==" + hex.EncodeToString(hash.Sum(nil))[:32] + "=="); err != nil {
+		return "", err
+	}
+
+	for i, part := range parts {
+		contentType := part.ContentType
+
+		if contentType == "" {
+			contentType = UserDataContentType(part.Content)
+		}
+
+		if contentType == "" {
+			return "", NewClientError(fmt.Sprintf("can not detect the content type of user data part %d", i))
+		}
+
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", mime.FormatMediaType(contentType, map[string]string{"charset": "utf-8"}))
+		header.Set("MIME-Version", "1.0")
+
+		if part.Filename != "" {
+			header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": part.Filename}))
+		}
+
+		partWriter, err := writer.CreatePart(header)
+
+		if err != nil {
+			return "", err
+		}
+
+		if _, err := partWriter.Write([]byte(part.Content)); err != nil {
+			return "", err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	userData := fmt.Sprintf("Content-Type: multipart/mixed; boundary=\"%s\"\nMIME-Version: 1.0\n\n%s", writer.Boundary(), body.String())
+
+	if len(userData) > MaxUserDataSize {
+		return "", NewClientError(fmt.Sprintf("multipart user data is %d bytes, the limit is %d bytes", len(userData), MaxUserDataSize))
+	}
+
+	return userData, nil
+}
+
+func toSet(values ...string) map[string]bool {
+	set := make(map[string]bool, len(values))
+
+	for _, value := range values {
+		set[value] = true
+	}
+
+	return set
+}
+
+func toStrings(values []interface{}) []string {
+	strs := make([]string, 0, len(values))
+
+	for _, value := range values {
+		str, _ := value.(string)
+		strs = append(strs, str)
+	}
+
+	return strs
+}
@@ -0,0 +1,174 @@
+package go_tilaa
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func gzipUserData(t *testing.T, content string) string {
+	var buffer bytes.Buffer
+
+	writer := gzip.NewWriter(&buffer)
+
+	if _, err := writer.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.String()
+}
+
+func TestValidateUserData(t *testing.T) {
+	tests := []struct {
+		name     string
+		userData string
+		strict   bool
+		metadata bool
+	}{
+		{"empty", "", true, true},
+		{"cloud-config", "#cloud-config\nhostname: web1\nruncmd:\n  - echo hi\n", true, true},
+		{"network", "#cloud-config\nnetwork:\n  version: 2\n", true, true},
+		{"script", "#!/bin/sh\necho hi\n", true, true},
+		{"part handler", "#part-handler\ndef list_types():\n  return []\n", true, true},
+		{"upstart job", "#upstart-job\ndescription \"job\"\n", true, true},
+		{"gzip", gzipUserData(t, "#cloud-config\nhostname: web1\n"), true, true},
+		{"unknown key", "#cloud-config\nhostnme: web1\n", false, true},
+		{"unknown header", "hello\n", false, true},
+		{"gzip unknown key", gzipUserData(t, "#cloud-config\nhostnme: web1\n"), false, true},
+		{"runcmd not a list", "#cloud-config\nruncmd: echo hi\n", false, true},
+		{"invalid yaml", "#cloud-config\nhostname: [web1\n", false, false},
+		{"too large", "#!/bin/sh\n" + strings.Repeat("#", MaxUserDataSize), false, false},
+	}
+
+	for _, test := range tests {
+		if err := ValidateUserData(test.userData); (err == nil) != test.strict {
+			t.Errorf("%s: ValidateUserData returned %v", test.name, err)
+		}
+
+		metadata := &Metadata{Name: "init", UserData: test.userData}
+
+		if err := metadata.Validate(); (err == nil) != test.metadata {
+			t.Errorf("%s: Metadata.Validate returned %v", test.name, err)
+		}
+	}
+}
+
+func TestNewMultipartUserData(t *testing.T) {
+	parts := []UserDataPart{
+		{Content: "#cloud-config\nhostname: web1\n"},
+		{Filename: "setup.sh", Content: "#!/bin/sh\necho hi\n"},
+		{ContentType: UserDataContentTypeCloudConfig, Content: "packages:\n  - nginx\n"},
+	}
+
+	userData, err := NewMultipartUserData(parts...)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	again, err := NewMultipartUserData(parts...)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if userData != again {
+		t.Error("the same parts produced different user data")
+	}
+
+	if UserDataContentType(userData) != UserDataContentTypeMultipart {
+		t.Errorf("detected %q", UserDataContentType(userData))
+	}
+
+	for _, fragment := range []string{
+		"Content-Type: text/cloud-config; charset=utf-8",
+		"Content-Type: text/x-shellscript; charset=utf-8",
+		"Content-Disposition: attachment; filename=setup.sh",
+	} {
+		if !strings.Contains(userData, fragment) {
+			t.Errorf("user data does not contain %q:\n%s", fragment, userData)
+		}
+	}
+
+	if err := ValidateUserData(userData); err != nil {
+		t.Errorf("multipart user data does not validate: %v", err)
+	}
+
+	parts[0].Content = "#cloud-config\nhostname: web2\n"
+
+	if changed, _ := NewMultipartUserData(parts...); changed == userData {
+		t.Error("different parts produced the same user data")
+	}
+}
+
+func TestNewMultipartUserDataErrors(t *testing.T) {
+	tests := map[string]UserDataPart{
+		"undetectable": {Content: "hello\n"},
+		"too large":    {Content: "#!/bin/sh\n" + strings.Repeat("#", MaxUserDataSize)},
+	}
+
+	for name, part := range tests {
+		var clientError *ClientError
+
+		if _, err := NewMultipartUserData(part); !errors.As(err, &clientError) {
+			t.Errorf("%s: expected a ClientError, got %v", name, err)
+		}
+	}
+}
+
+func TestValidateUserDataMultipart(t *testing.T) {
+	invalid, err := NewMultipartUserData(
+		UserDataPart{Content: "#!/bin/sh\necho hi\n"},
+		UserDataPart{ContentType: UserDataContentTypeCloudConfig, Content: "hostnme: web1\n"},
+	)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var validationError *ValidationError
+
+	if err := ValidateUserData(invalid); !errors.As(err, &validationError) || len(validationError.Field("user_data.part[1]")) != 1 {
+		t.Errorf("expected the second part to be reported, got %v", err)
+	}
+
+	if err := ValidateUserData("Content-Type: multipart/mixed\n\n"); err == nil {
+		t.Error("expected a multipart message without a boundary to be reported")
+	}
+}
+
+func TestUserDataSizeLimit(t *testing.T) {
+	script := "#!/bin/sh\n"
+	tests := []struct {
+		size     int
+		expected bool
+	}{
+		{MaxUserDataSize - 1, true},
+		{MaxUserDataSize, true},
+		{MaxUserDataSize + 1, false},
+	}
+
+	for _, test := range tests {
+		userData := script + strings.Repeat("#", test.size-len(script))
+
+		if err := ValidateUserData(userData); (err == nil) != test.expected {
+			t.Errorf("%d bytes: ValidateUserData returned %v", test.size, err)
+		}
+	}
+
+	compressed := gzipUserData(t, script+strings.Repeat("#", 2*MaxUserDataSize))
+
+	if len(compressed) > MaxUserDataSize {
+		t.Fatalf("compressed user data is %d bytes", len(compressed))
+	}
+
+	if err := ValidateUserData(compressed); err != nil {
+		t.Errorf("expected the limit to apply to the compressed size, got %v", err)
+	}
+}
@@ -1,28 +1,60 @@
 package go_tilaa
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"net/url"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 )
 
 const virtualMachinesBasePath = "virtual_machines"
 
+const (
+	MinCpuCores = 1
+	MaxCpuCores = 16
+
+	// The CPU cap is a percentage of a single core, 0 leaves the CPU uncapped
+	MinCpuCap        = 0
+	MaxCpuCapPerCore = 100
+
+	maxNameLength    = 64
+	maxDnsNameLength = 253
+)
+
+var (
+	validNamePattern     = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+	validDnsLabelPattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?$`)
+)
+
 type VirtualMachineServiceInterface interface {
 	List() (*[]VirtualMachine, error)
+	ListWithContext(context.Context) (*[]VirtualMachine, error)
 	Add(*VirtualMachine) (*VirtualMachine, error)
+	AddWithContext(context.Context, *VirtualMachine) (*VirtualMachine, error)
 	AddFromSnapshot(*VirtualMachine, *Snapshot) (*VirtualMachine, error)
+	AddFromSnapshotWithContext(context.Context, *VirtualMachine, *Snapshot) (*VirtualMachine, error)
 	View(int) (*VirtualMachine, error)
+	ViewWithContext(context.Context, int) (*VirtualMachine, error)
 	Edit(*VirtualMachine) (*VirtualMachine, error)
+	EditWithContext(context.Context, *VirtualMachine) (*VirtualMachine, error)
 	Cancel(*VirtualMachine, *time.Time) (*VirtualMachine, error)
+	CancelWithContext(context.Context, *VirtualMachine, *time.Time) (*VirtualMachine, error)
 	UndoCancellation(*VirtualMachine) error
+	UndoCancellationWithContext(context.Context, *VirtualMachine) error
 	GetCancelDates(*VirtualMachine) (*[]time.Time, error)
+	GetCancelDatesWithContext(context.Context, *VirtualMachine) (*[]time.Time, error)
 	RunTask(string, *VirtualMachine) (*VirtualMachine, error)
+	RunTaskWithContext(context.Context, string, *VirtualMachine) (*VirtualMachine, error)
 	Reinstall(*VirtualMachine) (*VirtualMachine, error)
+	ReinstallWithContext(context.Context, *VirtualMachine) (*VirtualMachine, error)
 	CreateSnapshot(*VirtualMachine, string, bool, bool) (*Snapshot, error)
+	CreateSnapshotWithContext(context.Context, *VirtualMachine, string, bool, bool) (*Snapshot, error)
 	RestoreSnapshot(*VirtualMachine, *Snapshot) (*VirtualMachine, error)
+	RestoreSnapshotWithContext(context.Context, *VirtualMachine, *Snapshot) (*VirtualMachine, error)
 }
 
 type VirtualMachineService struct {
@@ -40,6 +72,8 @@ type VirtualMachine struct {
 	Site      Site                 `json:"site"`
 	Template  Template             `json:"template"`
 	Network   []Network            `json:"network"`
+	SshKeys   ResourceIds          `json:"ssh_keys,omitempty"`
+	Metadata  ResourceId           `json:"metadata,omitempty"`
 	Admin     Admin                `json:"admin"`
 	Managed   bool                 `json:"is_managed"`
 	Locked    bool                 `json:"locked"`
@@ -104,27 +138,27 @@ type Admin struct {
 type VirtualMachineStatus string
 
 const (
-	VirtualMachineStatusPending                 = "pending"
-	VirtualMachineStatusCreateFailed            = "create_failed"
-	VirtualMachineStatusDestroyed               = "destroyed"
-	VirtualMachineStatusCreating                = "creating"
-	VirtualMachineStatusStopped                 = "stopped"
-	VirtualMachineStatusStarting                = "starting"
-	VirtualMachineStatusRestarting              = "restarting"
-	VirtualMachineStatusRunning                 = "running"
-	VirtualMachineStatusRunning_Rescue          = "running_rescue"
-	VirtualMachineStatusStopping                = "stopping"
-	VirtualMachineStatusResize_Failed           = "resize_failed"
-	VirtualMachineStatusDestroying              = "destroying"
-	VirtualMachineStatusDestroy_Failed          = "destroy_failed"
-	VirtualMachineStatusResizing                = "resizing"
-	VirtualMachineStatusMigrating               = "migrating"
-	VirtualMachineStatusMigrating_Live          = "live_migrating"
-	VirtualMachineStatusMigrate_Failed          = "migrate_failed"
-	VirtualMachineStatusPaused                  = "paused"
-	VirtualMachineStatusSnapshotCreating        = "creating_snapshot"
-	VirtualMachineStatusSnapshotRestoring       = "restoring_snapshot"
-	VirtualMachineStatusSnapshotRestoringFailed = "restore_snapshot_failed"
+	VirtualMachineStatusPending                 VirtualMachineStatus = "pending"
+	VirtualMachineStatusCreateFailed            VirtualMachineStatus = "create_failed"
+	VirtualMachineStatusDestroyed               VirtualMachineStatus = "destroyed"
+	VirtualMachineStatusCreating                VirtualMachineStatus = "creating"
+	VirtualMachineStatusStopped                 VirtualMachineStatus = "stopped"
+	VirtualMachineStatusStarting                VirtualMachineStatus = "starting"
+	VirtualMachineStatusRestarting              VirtualMachineStatus = "restarting"
+	VirtualMachineStatusRunning                 VirtualMachineStatus = "running"
+	VirtualMachineStatusRunning_Rescue          VirtualMachineStatus = "running_rescue"
+	VirtualMachineStatusStopping                VirtualMachineStatus = "stopping"
+	VirtualMachineStatusResize_Failed           VirtualMachineStatus = "resize_failed"
+	VirtualMachineStatusDestroying              VirtualMachineStatus = "destroying"
+	VirtualMachineStatusDestroy_Failed          VirtualMachineStatus = "destroy_failed"
+	VirtualMachineStatusResizing                VirtualMachineStatus = "resizing"
+	VirtualMachineStatusMigrating               VirtualMachineStatus = "migrating"
+	VirtualMachineStatusMigrating_Live          VirtualMachineStatus = "live_migrating"
+	VirtualMachineStatusMigrate_Failed          VirtualMachineStatus = "migrate_failed"
+	VirtualMachineStatusPaused                  VirtualMachineStatus = "paused"
+	VirtualMachineStatusSnapshotCreating        VirtualMachineStatus = "creating_snapshot"
+	VirtualMachineStatusSnapshotRestoring       VirtualMachineStatus = "restoring_snapshot"
+	VirtualMachineStatusSnapshotRestoringFailed VirtualMachineStatus = "restore_snapshot_failed"
 )
 
 type VirtualMachineResponse struct {
@@ -152,9 +186,13 @@ type CancelDatesResponse struct {
 }
 
 func (service *VirtualMachineService) List() (*[]VirtualMachine, error) {
+	return service.ListWithContext(context.Background())
+}
+
+func (service *VirtualMachineService) ListWithContext(ctx context.Context) (*[]VirtualMachine, error) {
 	var response VirtualMachinesResponse
 
-	_, err := service.client.Get(virtualMachinesBasePath, &response)
+	_, err := service.client.GetWithContext(ctx, virtualMachinesBasePath, &response)
 
 	if err != nil {
 		return nil, err
@@ -174,41 +212,41 @@ func (service *VirtualMachineService) List() (*[]VirtualMachine, error) {
 }
 
 func (service *VirtualMachineService) Add(machine *VirtualMachine) (*VirtualMachine, error) {
-	if err := machine.Validate(); err != nil {
-		return NewVirtualMachine(service.client), err
-	}
-
-	payload := machine.Payload()
-
-	var response NewVirtualMachineResponse
-
-	_, err := service.client.Post(virtualMachinesBasePath, payload, &response)
+	return service.AddWithContext(context.Background(), machine)
+}
 
-	if err != nil {
+func (service *VirtualMachineService) AddWithContext(ctx context.Context, machine *VirtualMachine) (*VirtualMachine, error) {
+	if err := machine.validate(ctx, service.client, false); err != nil {
 		return NewVirtualMachine(service.client), err
 	}
 
-	if response.Status == ResponseError {
-		err = NewApiError(response.Message)
-	}
-
-	machine.Id = response.Id
-
-	return machine, err
+	return service.add(ctx, machine, nil)
 }
 
 func (service *VirtualMachineService) AddFromSnapshot(machine *VirtualMachine, snapshot *Snapshot) (*VirtualMachine, error) {
-	if err := machine.Validate(); err != nil {
+	return service.AddFromSnapshotWithContext(context.Background(), machine, snapshot)
+}
+
+func (service *VirtualMachineService) AddFromSnapshotWithContext(ctx context.Context, machine *VirtualMachine, snapshot *Snapshot) (*VirtualMachine, error) {
+	if err := machine.validate(ctx, service.client, false); err != nil {
 		return NewVirtualMachine(service.client), err
 	}
 
+	return service.add(ctx, machine, snapshot)
+}
+
+// add creates the machine, from the snapshot when it is not nil, without validating it. It is used directly by
+// VirtualMachineSpec, which has validated the machine while building it.
+func (service *VirtualMachineService) add(ctx context.Context, machine *VirtualMachine, snapshot *Snapshot) (*VirtualMachine, error) {
 	payload := machine.Payload()
 
-	payload.Add("snapshot", strconv.Itoa(snapshot.Id))
+	if snapshot != nil {
+		payload.Add("snapshot", strconv.Itoa(snapshot.Id))
+	}
 
 	var response NewVirtualMachineResponse
 
-	_, err := service.client.Post(virtualMachinesBasePath, payload, &response)
+	_, err := service.client.PostWithContext(ctx, virtualMachinesBasePath, payload, &response)
 
 	if err != nil {
 		return NewVirtualMachine(service.client), err
@@ -224,9 +262,13 @@ func (service *VirtualMachineService) AddFromSnapshot(machine *VirtualMachine, s
 }
 
 func (service *VirtualMachineService) View(machineId int) (*VirtualMachine, error) {
+	return service.ViewWithContext(context.Background(), machineId)
+}
+
+func (service *VirtualMachineService) ViewWithContext(ctx context.Context, machineId int) (*VirtualMachine, error) {
 	var response VirtualMachineResponse
 
-	_, err := service.client.Get(service.path(strconv.Itoa(machineId)), &response)
+	_, err := service.client.GetWithContext(ctx, service.path(strconv.Itoa(machineId)), &response)
 
 	if err != nil {
 		return NewVirtualMachine(service.client), err
@@ -244,7 +286,11 @@ func (service *VirtualMachineService) View(machineId int) (*VirtualMachine, erro
 }
 
 func (service *VirtualMachineService) Edit(machine *VirtualMachine) (*VirtualMachine, error) {
-	if err := machine.Validate(); err != nil {
+	return service.EditWithContext(context.Background(), machine)
+}
+
+func (service *VirtualMachineService) EditWithContext(ctx context.Context, machine *VirtualMachine) (*VirtualMachine, error) {
+	if err := machine.validate(ctx, service.client, true); err != nil {
 		return machine, err
 	}
 
@@ -252,7 +298,7 @@ func (service *VirtualMachineService) Edit(machine *VirtualMachine) (*VirtualMac
 
 	var response StatusResponse
 
-	_, err := service.client.Post(service.path(strconv.Itoa(machine.Id)), payload, &response)
+	_, err := service.client.PostWithContext(ctx, service.path(strconv.Itoa(machine.Id)), payload, &response)
 
 	if err != nil {
 		return machine, err
@@ -266,13 +312,17 @@ func (service *VirtualMachineService) Edit(machine *VirtualMachine) (*VirtualMac
 }
 
 func (service *VirtualMachineService) Cancel(machine *VirtualMachine, date *time.Time) (*VirtualMachine, error) {
+	return service.CancelWithContext(context.Background(), machine, date)
+}
+
+func (service *VirtualMachineService) CancelWithContext(ctx context.Context, machine *VirtualMachine, date *time.Time) (*VirtualMachine, error) {
 	payload := &url.Values{
 		"date": {date.Format("2006-01-02")},
 	}
 
 	var response StatusResponse
 
-	_, err := service.client.Post(service.path(strconv.Itoa(machine.Id)+"/cancel"), payload, &response)
+	_, err := service.client.PostWithContext(ctx, service.path(strconv.Itoa(machine.Id)+"/cancel"), payload, &response)
 
 	if err != nil {
 		return machine, err
@@ -290,13 +340,17 @@ func (service *VirtualMachineService) Cancel(machine *VirtualMachine, date *time
 }
 
 func (service *VirtualMachineService) UndoCancellation(machine *VirtualMachine) error {
+	return service.UndoCancellationWithContext(context.Background(), machine)
+}
+
+func (service *VirtualMachineService) UndoCancellationWithContext(ctx context.Context, machine *VirtualMachine) error {
 	payload := &url.Values{
 		"date": {"0"},
 	}
 
 	var response StatusResponse
 
-	_, err := service.client.Post(service.path(strconv.Itoa(machine.Id)+"/cancel"), payload, &response)
+	_, err := service.client.PostWithContext(ctx, service.path(strconv.Itoa(machine.Id)+"/cancel"), payload, &response)
 
 	if err != nil {
 		return err
@@ -310,9 +364,13 @@ func (service *VirtualMachineService) UndoCancellation(machine *VirtualMachine)
 }
 
 func (service *VirtualMachineService) GetCancelDates(machine *VirtualMachine) (*[]time.Time, error) {
+	return service.GetCancelDatesWithContext(context.Background(), machine)
+}
+
+func (service *VirtualMachineService) GetCancelDatesWithContext(ctx context.Context, machine *VirtualMachine) (*[]time.Time, error) {
 	var response CancelDatesResponse
 
-	_, err := service.client.Get(service.path(strconv.Itoa(machine.Id)+"/cancel"), &response)
+	_, err := service.client.GetWithContext(ctx, service.path(strconv.Itoa(machine.Id)+"/cancel"), &response)
 
 	if err != nil {
 		return nil, err
@@ -326,26 +384,53 @@ func (service *VirtualMachineService) GetCancelDates(machine *VirtualMachine) (*
 }
 
 func (service *VirtualMachineService) RunTask(task string, machine *VirtualMachine) (*VirtualMachine, error) {
+	return service.RunTaskWithContext(context.Background(), task, machine)
+}
+
+func (service *VirtualMachineService) RunTaskWithContext(ctx context.Context, task string, machine *VirtualMachine) (*VirtualMachine, error) {
 	if !isValidTask(task) {
 		return machine, NewInvalidTaskError(task)
 	}
 
+	// The cached status may be stale, e.g. after an earlier task completed, so it is refreshed before refusing the task
+	if !machine.Status.CanRunTask(task) && machine.Id != 0 {
+		current, err := service.ViewWithContext(ctx, machine.Id)
+
+		if err != nil {
+			return machine, err
+		}
+
+		machine.Status = current.Status
+	}
+
+	if !machine.Status.CanRunTask(task) {
+		return machine, NewTaskNotAllowedError(task, machine.Status)
+	}
+
 	var response StatusResponse
 
-	_, err := service.client.Get(service.path(strconv.Itoa(machine.Id)+"/"+task), &response)
+	_, err := service.client.GetWithContext(ctx, service.path(strconv.Itoa(machine.Id)+"/"+task), &response)
 
 	if err != nil {
 		return machine, err
 	}
 
 	if response.Status == ResponseError {
-		err = NewApiError(response.Message)
+		return machine, NewApiError(response.Message)
 	}
 
-	return machine, err
+	if status, ok := virtualMachineTaskStatuses[task]; ok {
+		machine.Status = status
+	}
+
+	return machine, nil
 }
 
 func (service *VirtualMachineService) Reinstall(machine *VirtualMachine) (*VirtualMachine, error) {
+	return service.ReinstallWithContext(context.Background(), machine)
+}
+
+func (service *VirtualMachineService) ReinstallWithContext(ctx context.Context, machine *VirtualMachine) (*VirtualMachine, error) {
 	payload := &url.Values{
 		"template":          {strconv.Itoa(machine.Template.Id)},
 		"reinstall":         {"true"},
@@ -354,7 +439,7 @@ func (service *VirtualMachineService) Reinstall(machine *VirtualMachine) (*Virtu
 
 	var response StatusResponse
 
-	_, err := service.client.Post(service.path(strconv.Itoa(machine.Id)), payload, &response)
+	_, err := service.client.PostWithContext(ctx, service.path(strconv.Itoa(machine.Id)), payload, &response)
 
 	if err != nil {
 		return machine, err
@@ -368,7 +453,14 @@ func (service *VirtualMachineService) Reinstall(machine *VirtualMachine) (*Virtu
 }
 
 func (service *VirtualMachineService) CreateSnapshot(machine *VirtualMachine, name string, online bool, overwrite bool) (*Snapshot, error) {
-	// TODO: Endpoint does not return Snapshot ID after creation
+	return service.CreateSnapshotWithContext(context.Background(), machine, name, online, overwrite)
+}
+
+// CreateSnapshotWithContext creates a snapshot of the machine. The endpoint does not return the snapshot, so only its
+// name is set unless the client was configured through WithSnapshotResolution, in which case the snapshot is looked up
+// and returned once it is done.
+func (service *VirtualMachineService) CreateSnapshotWithContext(ctx context.Context, machine *VirtualMachine, name string, online bool, overwrite bool) (*Snapshot, error) {
+	started := time.Now()
 
 	payload := &url.Values{
 		"name": {name},
@@ -384,7 +476,7 @@ func (service *VirtualMachineService) CreateSnapshot(machine *VirtualMachine, na
 
 	var response StatusResponse
 
-	_, err := service.client.Post(service.path(strconv.Itoa(machine.Id)+"/create_snapshot"), payload, &response)
+	_, err := service.client.PostWithContext(ctx, service.path(strconv.Itoa(machine.Id)+"/create_snapshot"), payload, &response)
 
 	if err != nil {
 		return NewSnapshot(service.client), err
@@ -394,6 +486,17 @@ func (service *VirtualMachineService) CreateSnapshot(machine *VirtualMachine, na
 		err = NewApiError(response.Message)
 	}
 
+	if err == nil && service.client.snapshotWaiter != nil {
+		snapshot, err := service.client.snapshotWaiter.ResolveSnapshot(ctx, service.client, name, started)
+
+		if snapshot == nil {
+			snapshot = NewSnapshot(service.client)
+			snapshot.Name = name
+		}
+
+		return snapshot, err
+	}
+
 	snapshot := NewSnapshot(service.client)
 
 	snapshot.Name = name
@@ -402,13 +505,17 @@ func (service *VirtualMachineService) CreateSnapshot(machine *VirtualMachine, na
 }
 
 func (service *VirtualMachineService) RestoreSnapshot(machine *VirtualMachine, snapshot *Snapshot) (*VirtualMachine, error) {
+	return service.RestoreSnapshotWithContext(context.Background(), machine, snapshot)
+}
+
+func (service *VirtualMachineService) RestoreSnapshotWithContext(ctx context.Context, machine *VirtualMachine, snapshot *Snapshot) (*VirtualMachine, error) {
 	payload := &url.Values{
 		"snapshot": {strconv.Itoa(snapshot.Id)},
 	}
 
 	var response StatusResponse
 
-	_, err := service.client.Post(service.path(strconv.Itoa(machine.Id)+"/restore_snapshot"), payload, &response)
+	_, err := service.client.PostWithContext(ctx, service.path(strconv.Itoa(machine.Id)+"/restore_snapshot"), payload, &response)
 
 	if err != nil {
 		return machine, err
@@ -582,9 +689,8 @@ func (machine *VirtualMachine) RestoreSnapshot(snapshot *Snapshot) error {
 }
 
 func (machine *VirtualMachine) Payload() *url.Values {
-	return &url.Values{
+	payload := &url.Values{
 		"name":         {machine.Name},
-		"dns_name":     {machine.Network[0].DnsName},
 		"ram":          {strconv.Itoa(machine.Ram)},
 		"storage":      {strconv.Itoa(machine.Storage.Size)},
 		"storage_type": {string(machine.Storage.Type)},
@@ -593,15 +699,265 @@ func (machine *VirtualMachine) Payload() *url.Values {
 		"cpu_count":    {strconv.Itoa(machine.Cpu.Cores)},
 		"cpu_cap":      {strconv.Itoa(machine.Cpu.Cap)},
 	}
+
+	if dnsName := machine.DnsName(); dnsName != "" {
+		payload.Set("dns_name", dnsName)
+	}
+
+	for _, sshKeyId := range machine.SshKeys {
+		payload.Add("ssh_keys[]", strconv.Itoa(int(sshKeyId)))
+	}
+
+	if machine.Metadata != 0 {
+		payload.Set("metadata", strconv.Itoa(int(machine.Metadata)))
+	}
+
+	return payload
+}
+
+// AddSshKey provisions the given key on the machine when it is created.
+func (machine *VirtualMachine) AddSshKey(sshKey *SshKey) error {
+	if sshKey.Id == 0 {
+		return NewSshKeyNotCreatedError()
+	}
+
+	for _, sshKeyId := range machine.SshKeys {
+		if int(sshKeyId) == sshKey.Id {
+			return nil
+		}
+	}
+
+	machine.SshKeys = append(machine.SshKeys, ResourceId(sshKey.Id))
+
+	return nil
+}
+
+// SetMetadata provisions the machine with the given metadata, such as cloud-init user data, when it is created.
+func (machine *VirtualMachine) SetMetadata(metadata *Metadata) error {
+	if metadata.Id == 0 {
+		return NewMetadataNotCreatedError()
+	}
+
+	machine.Metadata = ResourceId(metadata.Id)
+
+	return nil
+}
+
+// DnsName returns the DNS name of the first network which has one.
+func (machine *VirtualMachine) DnsName() string {
+	for _, network := range machine.Network {
+		if network.DnsName != "" {
+			return network.DnsName
+		}
+	}
+
+	return ""
 }
 
 func (machine *VirtualMachine) Validate() error {
-	// TODO: Implement validation for all the fields, including by checking the permitted values for ram, storage, template, site and cpu
+	return machine.ValidateWithContext(context.Background())
+}
+
+// ValidateWithContext checks every field of the machine, including the RAM and storage sizes against the presets and
+// the template and site against the catalog, and returns a ValidationError listing every problem found.
+func (machine *VirtualMachine) ValidateWithContext(ctx context.Context) error {
+	return machine.validate(ctx, machine.client, false)
+}
+
+// validate checks the machine against the catalog of the given client, which may be nil to skip the catalog checks.
+// When changesOnly is set only the fields changed through the setters are checked, as an existing machine may use a
+// template which is no longer offered.
+func (machine *VirtualMachine) validate(ctx context.Context, client *Client, changesOnly bool) error {
+	validationError := NewValidationError()
+
+	machine.validateFields(changesOnly, validationError)
+
+	if client != nil {
+		if err := machine.validateCatalog(ctx, client, changesOnly, validationError); err != nil {
+			return err
+		}
+	}
+
+	if validationError.HasErrors() {
+		return validationError
+	}
+
 	return nil
 }
 
+// validateFields checks the fields which do not depend on the catalog.
+func (machine *VirtualMachine) validateFields(changesOnly bool, validationError *ValidationError) {
+	if !changesOnly || machine.original.Name != "" {
+		machine.validateName(validationError)
+	}
+
+	if !changesOnly {
+		machine.validateDnsNames(validationError)
+		machine.validateResources(validationError)
+	}
+
+	if !changesOnly || machine.original.Cpu.Cores != 0 || machine.original.Cpu.Cap != 0 {
+		machine.validateCpu(validationError)
+	}
+}
+
+func (machine *VirtualMachine) validateName(validationError *ValidationError) {
+	switch {
+	case machine.Name == "":
+		validationError.Add("name", "can not be empty")
+	case len(machine.Name) > maxNameLength:
+		validationError.Add("name", "can not be longer than %d characters", maxNameLength)
+	case !validNamePattern.MatchString(machine.Name):
+		validationError.Add("name", "%q may only contain letters, digits, dots, dashes and underscores", machine.Name)
+	}
+}
+
+func (machine *VirtualMachine) validateDnsNames(validationError *ValidationError) {
+	for _, network := range machine.Network {
+		if network.DnsName != "" && !isValidDnsName(network.DnsName) {
+			validationError.Add("dns_name", "%q is not a valid DNS name", network.DnsName)
+		}
+	}
+}
+
+func (machine *VirtualMachine) validateResources(validationError *ValidationError) {
+	for _, sshKeyId := range machine.SshKeys {
+		if sshKeyId <= 0 {
+			validationError.Add("ssh_keys", "%d is not a valid SSH key ID", sshKeyId)
+		}
+	}
+
+	if machine.Metadata < 0 {
+		validationError.Add("metadata", "%d is not a valid metadata ID", machine.Metadata)
+	}
+}
+
+func (machine *VirtualMachine) validateCpu(validationError *ValidationError) {
+	if machine.Cpu.Cores < MinCpuCores || machine.Cpu.Cores > MaxCpuCores {
+		validationError.Add("cpu_count", "%d is not between %d and %d", machine.Cpu.Cores, MinCpuCores, MaxCpuCores)
+	}
+
+	maxCap := machine.Cpu.Cores * MaxCpuCapPerCore
+
+	if machine.Cpu.Cap < MinCpuCap || machine.Cpu.Cap > maxCap {
+		validationError.Add("cpu_cap", "%d is not between %d and %d", machine.Cpu.Cap, MinCpuCap, maxCap)
+	}
+}
+
+func (machine *VirtualMachine) validateCatalog(ctx context.Context, client *Client, changesOnly bool, validationError *ValidationError) error {
+	if !changesOnly || machine.original.Ram != 0 || machine.original.Storage != 0 {
+		presets, err := client.Preset.ListWithContext(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		machine.validatePresets(presets, changesOnly, validationError)
+	}
+
+	if changesOnly {
+		return nil
+	}
+
+	templates, err := client.Template.ListWithContext(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	sites, err := client.Site.ListWithContext(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	machine.validateTemplateAndSite(*templates, *sites, validationError)
+
+	return nil
+}
+
+func (machine *VirtualMachine) validateTemplateAndSite(templates []Template, sites []Site, validationError *ValidationError) {
+	if !containsTemplate(templates, machine.Template.Id) {
+		validationError.Add("template", "%d is not an available template", machine.Template.Id)
+	}
+
+	if !containsSite(sites, machine.Site.Id) {
+		validationError.Add("site", "%d is not an available site", machine.Site.Id)
+	}
+}
+
+func (machine *VirtualMachine) validatePresets(presets *Presets, changesOnly bool, validationError *ValidationError) {
+	if (!changesOnly || machine.original.Ram != 0) && !presets.ValidRam(machine.Ram) {
+		validationError.Add("ram", "%d is not one of the available sizes %v", machine.Ram, presets.RamSizes())
+	}
+
+	if changesOnly && machine.original.Storage == 0 {
+		return
+	}
+
+	if !presets.ValidStorageType(machine.Storage.Type) {
+		validationError.Add("storage_type", "%q is not one of the available storage types %v", machine.Storage.Type, presets.StorageTypes())
+
+		return
+	}
+
+	if !presets.ValidStorage(machine.Storage.Type, machine.Storage.Size) {
+		validationError.Add("storage", "%d is not one of the available %s sizes %v", machine.Storage.Size, machine.Storage.Type, presets.StorageSizes(machine.Storage.Type))
+	}
+}
+
+func isValidDnsName(name string) bool {
+	name = strings.TrimSuffix(name, ".")
+
+	if name == "" || len(name) > maxDnsNameLength {
+		return false
+	}
+
+	for _, label := range strings.Split(name, ".") {
+		if !validDnsLabelPattern.MatchString(label) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func containsSize(sizes []int, size int) bool {
+	for _, available := range sizes {
+		if available == size {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsTemplate(templates []Template, templateId int) bool {
+	for _, template := range templates {
+		if template.Id == templateId {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsSite(sites []Site, siteId int) bool {
+	for _, site := range sites {
+		if site.Id == siteId {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (machine *VirtualMachine) Refresh() error {
-	update, err := machine.client.VirtualMachine.View(machine.Id)
+	return machine.RefreshWithContext(context.Background())
+}
+
+func (machine *VirtualMachine) RefreshWithContext(ctx context.Context) error {
+	update, err := machine.client.VirtualMachine.ViewWithContext(ctx, machine.Id)
 
 	if err != nil {
 		return err
@@ -614,6 +970,8 @@ func (machine *VirtualMachine) Refresh() error {
 	machine.Site = update.Site
 	machine.Template = update.Template
 	machine.Network = update.Network
+	machine.SshKeys = update.SshKeys
+	machine.Metadata = update.Metadata
 	machine.Admin = update.Admin
 	machine.Managed = update.Managed
 	machine.Locked = update.Locked
@@ -693,6 +1051,49 @@ func (machine *VirtualMachine) SetRam(size int) error {
 	return nil
 }
 
+// SetNearestRam sets the RAM to the available preset size closest to the given size, rounding up or down.
+func (machine *VirtualMachine) SetNearestRam(presets *Presets, size int, roundUp bool) error {
+	nearest, ok := presets.NearestRam(size, roundUp)
+
+	if !ok {
+		validationError := NewValidationError()
+		validationError.Add("ram", "no available size near %d in %v", size, presets.RamSizes())
+
+		return validationError
+	}
+
+	return machine.SetRam(nearest)
+}
+
+func (machine *VirtualMachine) SetStorage(size int) error {
+	if machine.Id == 0 {
+		return NewVirtualMachineNotCreatedError()
+	}
+
+	if machine.original.Storage == 0 {
+		machine.original.Storage = machine.Storage.Size
+	}
+
+	machine.Storage.Size = size
+
+	return nil
+}
+
+// SetNearestStorage sets the storage to the available preset size of the machine's storage type closest to the given
+// size, rounding up or down.
+func (machine *VirtualMachine) SetNearestStorage(presets *Presets, size int, roundUp bool) error {
+	nearest, ok := presets.NearestStorage(machine.Storage.Type, size, roundUp)
+
+	if !ok {
+		validationError := NewValidationError()
+		validationError.Add("storage", "no available %s size near %d in %v", machine.Storage.Type, size, presets.StorageSizes(machine.Storage.Type))
+
+		return validationError
+	}
+
+	return machine.SetStorage(nearest)
+}
+
 func (machine *VirtualMachine) SetCpuCores(cores int) error {
 	if machine.Id == 0 {
 		return NewVirtualMachineNotCreatedError()
@@ -725,6 +1126,12 @@ func NewVirtualMachine(client *Client) *VirtualMachine {
 	return &VirtualMachine{client: client}
 }
 
+// SetClient binds the machine to the given client, which is used by methods such as Start and Commit. Services which
+// do not come from this package, such as mocks, use it to bind the machines they return.
+func (machine *VirtualMachine) SetClient(client *Client) {
+	machine.client = client
+}
+
 func isValidTask(task string) bool {
 	switch task {
 	case
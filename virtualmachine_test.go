@@ -0,0 +1,75 @@
+package go_tilaa_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+	"github.com/pascal-splotches/go-tilaa/tilaatest"
+)
+
+func TestRunTaskSetsTransitionalStatus(t *testing.T) {
+	server := tilaatest.NewServer()
+	defer server.Close()
+
+	client, err := server.Client()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	machineId := server.AddVirtualMachine(go_tilaa.VirtualMachine{Name: "web1"})
+	machine, err := client.VirtualMachine.View(machineId)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.VirtualMachine.RunTask(go_tilaa.TaskStop, machine); err != nil {
+		t.Fatal(err)
+	}
+
+	if machine.Status != go_tilaa.VirtualMachineStatusStopping {
+		t.Errorf("expected status %s, got %s", go_tilaa.VirtualMachineStatusStopping, machine.Status)
+	}
+}
+
+func TestRunTaskRefreshesStaleStatus(t *testing.T) {
+	server := tilaatest.NewServer()
+	defer server.Close()
+
+	client, err := server.Client()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	machineId := server.AddVirtualMachine(go_tilaa.VirtualMachine{Name: "web1"})
+	machine, err := client.VirtualMachine.View(machineId)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The machine was stopped elsewhere, the cached status still reads running
+	server.SetStatus(machineId, go_tilaa.VirtualMachineStatusStopped)
+
+	if _, err := client.VirtualMachine.RunTask(go_tilaa.TaskStart, machine); err != nil {
+		t.Fatal(err)
+	}
+
+	if machine.Status != go_tilaa.VirtualMachineStatusStarting {
+		t.Errorf("expected status %s, got %s", go_tilaa.VirtualMachineStatusStarting, machine.Status)
+	}
+
+	server.SetStatus(machineId, go_tilaa.VirtualMachineStatusResizing)
+
+	_, err = client.VirtualMachine.RunTaskWithContext(context.Background(), go_tilaa.TaskStop, machine)
+
+	var notAllowed *go_tilaa.TaskNotAllowedError
+
+	if !errors.As(err, &notAllowed) {
+		t.Errorf("expected a TaskNotAllowedError, got %v", err)
+	}
+}
@@ -0,0 +1,270 @@
+package go_tilaa
+
+import (
+	"context"
+	"strings"
+)
+
+// VirtualMachineSpec builds a VirtualMachine for creation. Templates and sites may be given by name, they are resolved
+// to IDs through the catalog services when the spec is built:
+//
+//	machine, err := go_tilaa.NewVirtualMachineSpec(client).
+//		WithName("web1").
+//		WithTemplateName("Ubuntu 18.04").
+//		WithSite("AMS1").
+//		WithRam(2048).
+//		WithSsd(20).
+//		WithCpu(2).
+//		WithSshKeys(sshKey.Id).
+//		WithMetadata(metadata.Id).
+//		Create(ctx)
+type VirtualMachineSpec struct {
+	client *Client
+
+	name         string
+	dnsName      string
+	templateId   int
+	templateName string
+	siteId       int
+	siteName     string
+	ram          int
+	storage      Storage
+	cpu          Cpu
+	sshKeys      ResourceIds
+	metadata     ResourceId
+}
+
+func NewVirtualMachineSpec(client *Client) *VirtualMachineSpec {
+	return &VirtualMachineSpec{
+		client: client,
+		cpu:    Cpu{Cores: MinCpuCores, Cap: MaxCpuCapPerCore},
+	}
+}
+
+func (spec *VirtualMachineSpec) WithName(name string) *VirtualMachineSpec {
+	spec.name = name
+
+	return spec
+}
+
+func (spec *VirtualMachineSpec) WithDnsName(dnsName string) *VirtualMachineSpec {
+	spec.dnsName = dnsName
+
+	return spec
+}
+
+func (spec *VirtualMachineSpec) WithTemplate(templateId int) *VirtualMachineSpec {
+	spec.templateId = templateId
+	spec.templateName = ""
+
+	return spec
+}
+
+func (spec *VirtualMachineSpec) WithTemplateName(name string) *VirtualMachineSpec {
+	spec.templateName = name
+	spec.templateId = 0
+
+	return spec
+}
+
+func (spec *VirtualMachineSpec) WithSite(name string) *VirtualMachineSpec {
+	spec.siteName = name
+	spec.siteId = 0
+
+	return spec
+}
+
+func (spec *VirtualMachineSpec) WithSiteId(siteId int) *VirtualMachineSpec {
+	spec.siteId = siteId
+	spec.siteName = ""
+
+	return spec
+}
+
+func (spec *VirtualMachineSpec) WithRam(size int) *VirtualMachineSpec {
+	spec.ram = size
+
+	return spec
+}
+
+func (spec *VirtualMachineSpec) WithSsd(size int) *VirtualMachineSpec {
+	spec.storage = Storage{Size: size, Type: StorageTypeSsd}
+
+	return spec
+}
+
+func (spec *VirtualMachineSpec) WithHdd(size int) *VirtualMachineSpec {
+	spec.storage = Storage{Size: size, Type: StorageTypeHdd}
+
+	return spec
+}
+
+// WithCpu sets the number of cores, capping the machine at the full capacity of those cores.
+func (spec *VirtualMachineSpec) WithCpu(cores int) *VirtualMachineSpec {
+	spec.cpu = Cpu{Cores: cores, Cap: cores * MaxCpuCapPerCore}
+
+	return spec
+}
+
+func (spec *VirtualMachineSpec) WithSshKeys(sshKeyIds ...int) *VirtualMachineSpec {
+	for _, sshKeyId := range sshKeyIds {
+		spec.sshKeys = append(spec.sshKeys, ResourceId(sshKeyId))
+	}
+
+	return spec
+}
+
+func (spec *VirtualMachineSpec) WithMetadata(metadataId int) *VirtualMachineSpec {
+	spec.metadata = ResourceId(metadataId)
+
+	return spec
+}
+
+func (spec *VirtualMachineSpec) WithCpuCap(cap int) *VirtualMachineSpec {
+	spec.cpu.Cap = cap
+
+	return spec
+}
+
+// Build resolves the template and site names and validates the resulting machine. When no RAM or storage size is
+// given the template defaults are used, on SSD storage unless WithHdd was given. Every catalog is listed only once.
+func (spec *VirtualMachineSpec) Build(ctx context.Context) (*VirtualMachine, error) {
+	machine := NewVirtualMachine(spec.client)
+
+	machine.Name = spec.name
+	machine.Ram = spec.ram
+	machine.Storage = spec.storage
+	machine.Cpu = spec.cpu
+	machine.Template.Id = spec.templateId
+	machine.Site.Id = spec.siteId
+	machine.SshKeys = spec.sshKeys
+	machine.Metadata = spec.metadata
+
+	if spec.dnsName != "" {
+		machine.Network = []Network{{DnsName: spec.dnsName}}
+	}
+
+	templates, err := spec.client.Template.ListWithContext(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sites, err := spec.client.Site.ListWithContext(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	validationError := NewValidationError()
+
+	spec.resolveTemplate(*templates, machine, validationError)
+	spec.resolveSite(*sites, machine, validationError)
+
+	if validationError.HasErrors() {
+		return nil, validationError
+	}
+
+	presets, err := spec.client.Preset.ListWithContext(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	machine.validateFields(false, validationError)
+	machine.validatePresets(presets, false, validationError)
+	machine.validateTemplateAndSite(*templates, *sites, validationError)
+
+	if validationError.HasErrors() {
+		return nil, validationError
+	}
+
+	return machine, nil
+}
+
+// Create builds the machine and adds it through the VirtualMachine service, without validating it a second time.
+func (spec *VirtualMachineSpec) Create(ctx context.Context) (*VirtualMachine, error) {
+	machine, err := spec.Build(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if service, ok := spec.client.VirtualMachine.(*VirtualMachineService); ok {
+		return service.add(ctx, machine, nil)
+	}
+
+	return spec.client.VirtualMachine.AddWithContext(ctx, machine)
+}
+
+// CreateFromSnapshot builds the machine and adds it from the given snapshot through the VirtualMachine service, without
+// validating it a second time.
+func (spec *VirtualMachineSpec) CreateFromSnapshot(ctx context.Context, snapshot *Snapshot) (*VirtualMachine, error) {
+	if snapshot.Id == 0 {
+		return nil, NewSnapshotNotCreatedError()
+	}
+
+	machine, err := spec.Build(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if service, ok := spec.client.VirtualMachine.(*VirtualMachineService); ok {
+		return service.add(ctx, machine, snapshot)
+	}
+
+	return spec.client.VirtualMachine.AddFromSnapshotWithContext(ctx, machine, snapshot)
+}
+
+func (spec *VirtualMachineSpec) resolveTemplate(templates []Template, machine *VirtualMachine, validationError *ValidationError) {
+	if spec.templateId == 0 && spec.templateName == "" {
+		validationError.Add("template", "is required")
+
+		return
+	}
+
+	for _, template := range templates {
+		if (spec.templateName != "" && strings.EqualFold(template.Name, spec.templateName)) || (spec.templateName == "" && template.Id == spec.templateId) {
+			machine.Template = template
+
+			if machine.Ram == 0 {
+				machine.Ram = template.Ram
+			}
+
+			if machine.Storage.Size == 0 {
+				machine.Storage.Size = template.Storage
+			}
+
+			if machine.Storage.Type == "" {
+				machine.Storage.Type = StorageTypeSsd
+			}
+
+			return
+		}
+	}
+
+	if spec.templateName != "" {
+		validationError.Add("template", "no template named %q", spec.templateName)
+	}
+}
+
+func (spec *VirtualMachineSpec) resolveSite(sites []Site, machine *VirtualMachine, validationError *ValidationError) {
+	if spec.siteName == "" {
+		if spec.siteId == 0 {
+			validationError.Add("site", "is required")
+		}
+
+		return
+	}
+
+	for _, site := range sites {
+		if strings.EqualFold(site.Name, spec.siteName) {
+			machine.Site = site
+
+			return
+		}
+	}
+
+	validationError.Add("site", "no site named %q", spec.siteName)
+}
@@ -0,0 +1,101 @@
+package go_tilaa_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+	"github.com/pascal-splotches/go-tilaa/tilaatest"
+)
+
+// countingTransport counts the requests per path.
+type countingTransport struct {
+	mutex  sync.Mutex
+	counts map[string]int
+}
+
+func (transport *countingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	transport.mutex.Lock()
+	transport.counts[request.Method+" "+strings.TrimPrefix(request.URL.Path, "/")]++
+	transport.mutex.Unlock()
+
+	return http.DefaultTransport.RoundTrip(request)
+}
+
+func TestVirtualMachineSpecCreateUsesTemplateDefaults(t *testing.T) {
+	server := tilaatest.NewServer()
+	defer server.Close()
+
+	transport := &countingTransport{counts: map[string]int{}}
+	client, err := server.Client(go_tilaa.WithHttpClient(&http.Client{Transport: transport}))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	machine, err := go_tilaa.NewVirtualMachineSpec(client).
+		WithName("web1").
+		WithTemplateName("Ubuntu 20.04").
+		WithSite("AMS1").
+		Create(context.Background())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if machine.Id == 0 {
+		t.Error("machine was not created")
+	}
+
+	expected := go_tilaa.Storage{Size: 10, Type: go_tilaa.StorageTypeSsd}
+
+	if machine.Storage != expected || machine.Ram != 1024 {
+		t.Errorf("expected the template defaults, got %d MB RAM and %+v", machine.Ram, machine.Storage)
+	}
+
+	// Build lists every catalog once to resolve the names and validate, Create does not validate again
+	expectedCounts := map[string]int{"GET v1/presets": 1, "GET v1/templates": 1, "GET v1/sites": 1}
+
+	for request, expectedCount := range expectedCounts {
+		if count := transport.counts[request]; count != expectedCount {
+			t.Errorf("%s: expected %d requests, got %d", request, expectedCount, count)
+		}
+	}
+}
+
+func TestVirtualMachineAddValidatesMachineEditedAfterBuild(t *testing.T) {
+	server := tilaatest.NewServer()
+	defer server.Close()
+
+	client, err := server.Client()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	machine, err := go_tilaa.NewVirtualMachineSpec(client).
+		WithName("web1").
+		WithTemplateName("Ubuntu 20.04").
+		WithSite("AMS1").
+		Build(context.Background())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	machine.Ram = 3000
+
+	var validationError *go_tilaa.ValidationError
+
+	if _, err := client.VirtualMachine.AddWithContext(context.Background(), machine); !errors.As(err, &validationError) {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+
+	if len(validationError.Field("ram")) != 1 {
+		t.Errorf("expected the RAM size to be rejected, got %v", validationError)
+	}
+}
@@ -0,0 +1,178 @@
+package go_tilaa
+
+// virtualMachineTransitions documents the statuses a virtual machine is expected to move to from a given status.
+// Stable statuses move into a transitional status when a task or edit is run, transitional statuses settle into a
+// stable or failed status.
+//
+//	pending                 -> creating, create_failed
+//	creating                -> running, stopped, create_failed
+//	stopped                 -> starting, running_rescue, resizing, destroying, creating_snapshot, restoring_snapshot, migrating
+//	starting                -> running, stopped
+//	running                 -> stopping, restarting, running_rescue, resizing, destroying, creating_snapshot, restoring_snapshot, migrating, live_migrating, paused
+//	running_rescue          -> stopping, restarting, stopped
+//	restarting              -> running, stopped
+//	stopping                -> stopped, running
+//	paused                  -> running, stopped
+//	resizing                -> running, stopped, resize_failed
+//	resize_failed           -> starting, stopping, resizing, destroying
+//	migrating               -> running, stopped, migrate_failed
+//	live_migrating          -> running, migrate_failed
+//	migrate_failed          -> starting, stopping, migrating, destroying
+//	creating_snapshot       -> running, stopped
+//	restoring_snapshot      -> running, stopped, restore_snapshot_failed
+//	restore_snapshot_failed -> starting, stopping, restoring_snapshot, destroying
+//	destroying              -> destroyed, destroy_failed
+//	destroy_failed          -> destroying
+//	create_failed, destroyed are terminal
+var virtualMachineTransitions = map[VirtualMachineStatus][]VirtualMachineStatus{
+	VirtualMachineStatusPending:                 {VirtualMachineStatusCreating, VirtualMachineStatusCreateFailed},
+	VirtualMachineStatusCreating:                {VirtualMachineStatusRunning, VirtualMachineStatusStopped, VirtualMachineStatusCreateFailed},
+	VirtualMachineStatusStopped:                 {VirtualMachineStatusStarting, VirtualMachineStatusRunning_Rescue, VirtualMachineStatusResizing, VirtualMachineStatusDestroying, VirtualMachineStatusSnapshotCreating, VirtualMachineStatusSnapshotRestoring, VirtualMachineStatusMigrating},
+	VirtualMachineStatusStarting:                {VirtualMachineStatusRunning, VirtualMachineStatusStopped},
+	VirtualMachineStatusRunning:                 {VirtualMachineStatusStopping, VirtualMachineStatusRestarting, VirtualMachineStatusRunning_Rescue, VirtualMachineStatusResizing, VirtualMachineStatusDestroying, VirtualMachineStatusSnapshotCreating, VirtualMachineStatusSnapshotRestoring, VirtualMachineStatusMigrating, VirtualMachineStatusMigrating_Live, VirtualMachineStatusPaused},
+	VirtualMachineStatusRunning_Rescue:          {VirtualMachineStatusStopping, VirtualMachineStatusRestarting, VirtualMachineStatusStopped},
+	VirtualMachineStatusRestarting:              {VirtualMachineStatusRunning, VirtualMachineStatusStopped},
+	VirtualMachineStatusStopping:                {VirtualMachineStatusStopped, VirtualMachineStatusRunning},
+	VirtualMachineStatusPaused:                  {VirtualMachineStatusRunning, VirtualMachineStatusStopped},
+	VirtualMachineStatusResizing:                {VirtualMachineStatusRunning, VirtualMachineStatusStopped, VirtualMachineStatusResize_Failed},
+	VirtualMachineStatusResize_Failed:           {VirtualMachineStatusStarting, VirtualMachineStatusStopping, VirtualMachineStatusResizing, VirtualMachineStatusDestroying},
+	VirtualMachineStatusMigrating:               {VirtualMachineStatusRunning, VirtualMachineStatusStopped, VirtualMachineStatusMigrate_Failed},
+	VirtualMachineStatusMigrating_Live:          {VirtualMachineStatusRunning, VirtualMachineStatusMigrate_Failed},
+	VirtualMachineStatusMigrate_Failed:          {VirtualMachineStatusStarting, VirtualMachineStatusStopping, VirtualMachineStatusMigrating, VirtualMachineStatusDestroying},
+	VirtualMachineStatusSnapshotCreating:        {VirtualMachineStatusRunning, VirtualMachineStatusStopped},
+	VirtualMachineStatusSnapshotRestoring:       {VirtualMachineStatusRunning, VirtualMachineStatusStopped, VirtualMachineStatusSnapshotRestoringFailed},
+	VirtualMachineStatusSnapshotRestoringFailed: {VirtualMachineStatusStarting, VirtualMachineStatusStopping, VirtualMachineStatusSnapshotRestoring, VirtualMachineStatusDestroying},
+	VirtualMachineStatusDestroying:              {VirtualMachineStatusDestroyed, VirtualMachineStatusDestroy_Failed},
+	VirtualMachineStatusDestroy_Failed:          {VirtualMachineStatusDestroying},
+	VirtualMachineStatusCreateFailed:            {},
+	VirtualMachineStatusDestroyed:               {},
+}
+
+// virtualMachineTasks lists the tasks which may be run in a given status. Statuses which are not listed, for example
+// statuses introduced by the API after this library was written, allow every task.
+var virtualMachineTasks = map[VirtualMachineStatus][]string{
+	VirtualMachineStatusPending:                 {},
+	VirtualMachineStatusCreating:                {},
+	VirtualMachineStatusStopped:                 {TaskStart, TaskRescue},
+	VirtualMachineStatusStarting:                {TaskPowerOff},
+	VirtualMachineStatusRunning:                 {TaskStop, TaskRestart, TaskPowerOff, TaskRescue},
+	VirtualMachineStatusRunning_Rescue:          {TaskStop, TaskRestart, TaskPowerOff},
+	VirtualMachineStatusRestarting:              {TaskPowerOff},
+	VirtualMachineStatusStopping:                {TaskPowerOff},
+	VirtualMachineStatusPaused:                  {TaskStart, TaskPowerOff},
+	VirtualMachineStatusResizing:                {},
+	VirtualMachineStatusResize_Failed:           {TaskStart, TaskStop, TaskRestart, TaskPowerOff, TaskRescue},
+	VirtualMachineStatusMigrating:               {},
+	VirtualMachineStatusMigrating_Live:          {},
+	VirtualMachineStatusMigrate_Failed:          {TaskStart, TaskStop, TaskRestart, TaskPowerOff, TaskRescue},
+	VirtualMachineStatusSnapshotCreating:        {},
+	VirtualMachineStatusSnapshotRestoring:       {},
+	VirtualMachineStatusSnapshotRestoringFailed: {TaskStart, TaskStop, TaskRestart, TaskPowerOff, TaskRescue},
+	VirtualMachineStatusDestroying:              {},
+	VirtualMachineStatusDestroy_Failed:          {},
+	VirtualMachineStatusCreateFailed:            {},
+	VirtualMachineStatusDestroyed:               {},
+}
+
+// virtualMachineTaskStatuses lists the transitional status a machine moves into once a task has been accepted.
+var virtualMachineTaskStatuses = map[string]VirtualMachineStatus{
+	TaskStart:    VirtualMachineStatusStarting,
+	TaskStop:     VirtualMachineStatusStopping,
+	TaskRestart:  VirtualMachineStatusRestarting,
+	TaskPowerOff: VirtualMachineStatusStopping,
+	TaskRescue:   VirtualMachineStatusRestarting,
+}
+
+// IsTransitional reports whether the machine is busy moving between two stable statuses.
+func (status VirtualMachineStatus) IsTransitional() bool {
+	switch status {
+	case
+		VirtualMachineStatusPending,
+		VirtualMachineStatusCreating,
+		VirtualMachineStatusStarting,
+		VirtualMachineStatusRestarting,
+		VirtualMachineStatusStopping,
+		VirtualMachineStatusResizing,
+		VirtualMachineStatusDestroying,
+		VirtualMachineStatusMigrating,
+		VirtualMachineStatusMigrating_Live,
+		VirtualMachineStatusSnapshotCreating,
+		VirtualMachineStatusSnapshotRestoring:
+		return true
+	}
+
+	return false
+}
+
+// IsFailed reports whether the last operation on the machine failed.
+func (status VirtualMachineStatus) IsFailed() bool {
+	switch status {
+	case
+		VirtualMachineStatusCreateFailed,
+		VirtualMachineStatusResize_Failed,
+		VirtualMachineStatusDestroy_Failed,
+		VirtualMachineStatusMigrate_Failed,
+		VirtualMachineStatusSnapshotRestoringFailed:
+		return true
+	}
+
+	return false
+}
+
+// IsTerminal reports whether the machine can never leave its status again.
+func (status VirtualMachineStatus) IsTerminal() bool {
+	transitions, ok := virtualMachineTransitions[status]
+
+	return ok && len(transitions) == 0
+}
+
+// Transitions returns the statuses the machine is expected to be able to move to directly, or nil if the status is
+// unknown.
+func (status VirtualMachineStatus) Transitions() []VirtualMachineStatus {
+	transitions, ok := virtualMachineTransitions[status]
+
+	if !ok {
+		return nil
+	}
+
+	return append([]VirtualMachineStatus{}, transitions...)
+}
+
+// CanTransitionTo reports whether the machine is expected to be able to move directly to the given status.
+func (status VirtualMachineStatus) CanTransitionTo(next VirtualMachineStatus) bool {
+	transitions, ok := virtualMachineTransitions[status]
+
+	if !ok {
+		return true
+	}
+
+	for _, transition := range transitions {
+		if transition == next {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CanRunTask reports whether the given task may be run while the machine is in this status. An empty status, for
+// example of a machine which has not been refreshed, allows every task.
+func (status VirtualMachineStatus) CanRunTask(task string) bool {
+	if !isValidTask(task) {
+		return false
+	}
+
+	tasks, ok := virtualMachineTasks[status]
+
+	if !ok {
+		return true
+	}
+
+	for _, allowed := range tasks {
+		if allowed == task {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,204 @@
+package go_tilaa
+
+import (
+	"context"
+	"time"
+)
+
+// Waiter polls a VirtualMachine or Snapshot until it reaches one of the requested statuses. The delay between two polls starts at
+// Interval and is multiplied by Multiplier after every poll, up to MaxInterval.
+type Waiter struct {
+	Interval    time.Duration
+	MaxInterval time.Duration
+	Multiplier  float64
+}
+
+var DefaultWaiter = Waiter{
+	Interval:    2 * time.Second,
+	MaxInterval: 30 * time.Second,
+	Multiplier:  1.5,
+}
+
+// WaitForStatus blocks until the machine reaches one of the given statuses using the DefaultWaiter. Use
+// context.WithTimeout to bound the wait.
+func WaitForStatus(ctx context.Context, machine *VirtualMachine, statuses ...VirtualMachineStatus) error {
+	return DefaultWaiter.WaitForStatus(ctx, machine, statuses...)
+}
+
+func WaitUntilRunning(ctx context.Context, machine *VirtualMachine) error {
+	return DefaultWaiter.WaitUntilRunning(ctx, machine)
+}
+
+func WaitUntilStopped(ctx context.Context, machine *VirtualMachine) error {
+	return DefaultWaiter.WaitUntilStopped(ctx, machine)
+}
+
+func WaitUntilCreated(ctx context.Context, machine *VirtualMachine) error {
+	return DefaultWaiter.WaitUntilCreated(ctx, machine)
+}
+
+// ResolveSnapshot finds a snapshot created through CreateSnapshot and waits until it is done using the DefaultWaiter.
+func ResolveSnapshot(ctx context.Context, client *Client, name string, since time.Time) (*Snapshot, error) {
+	return DefaultWaiter.ResolveSnapshot(ctx, client, name, since)
+}
+
+func WaitForSnapshot(ctx context.Context, snapshot *Snapshot) error {
+	return DefaultWaiter.WaitForSnapshot(ctx, snapshot)
+}
+
+func (waiter Waiter) WaitUntilRunning(ctx context.Context, machine *VirtualMachine) error {
+	return waiter.WaitForStatus(ctx, machine, VirtualMachineStatusRunning)
+}
+
+func (waiter Waiter) WaitUntilStopped(ctx context.Context, machine *VirtualMachine) error {
+	return waiter.WaitForStatus(ctx, machine, VirtualMachineStatusStopped)
+}
+
+// WaitUntilCreated waits for a newly added machine to finish provisioning, after which it is either running or
+// stopped.
+func (waiter Waiter) WaitUntilCreated(ctx context.Context, machine *VirtualMachine) error {
+	return waiter.WaitForStatus(ctx, machine, VirtualMachineStatusRunning, VirtualMachineStatusStopped)
+}
+
+// WaitForStatus refreshes the machine until its status matches one of the given statuses. It fails early when the
+// machine enters a failed state, and returns a WaitTimeoutError describing the last observed status when the context
+// is done first. A failed status the machine already had when the wait started, e.g. resize_failed when starting a
+// machine which failed to resize earlier, is left over from an earlier operation and is waited out instead. Any other
+// failed status fails the wait, also when it is the first status observed.
+func (waiter Waiter) WaitForStatus(ctx context.Context, machine *VirtualMachine, statuses ...VirtualMachineStatus) error {
+	if machine.Id == 0 {
+		return NewVirtualMachineNotCreatedError()
+	}
+
+	interval := waiter.Interval
+
+	var leftover VirtualMachineStatus
+
+	if machine.Status.IsFailed() {
+		leftover = machine.Status
+	}
+
+	movedOn := false
+
+	for {
+		if err := machine.RefreshWithContext(ctx); err != nil {
+			if ctx.Err() != nil {
+				return NewWaitTimeoutError(machine.Status, statuses, ctx.Err())
+			}
+
+			return err
+		}
+
+		for _, status := range statuses {
+			if machine.Status == status {
+				return nil
+			}
+		}
+
+		if machine.Status.IsTerminal() || (machine.Status.IsFailed() && (movedOn || machine.Status != leftover)) {
+			return NewVirtualMachineFailedError(machine.Status)
+		}
+
+		if !machine.Status.IsFailed() {
+			movedOn = true
+		}
+
+		if err := sleepWithContext(ctx, interval); err != nil {
+			return NewWaitTimeoutError(machine.Status, statuses, err)
+		}
+
+		interval = waiter.next(interval)
+	}
+}
+
+// ResolveSnapshot lists the snapshots until one with the given name, created at or after since, appears and then waits
+// until it is done. The most recently created snapshot is used when several match.
+func (waiter Waiter) ResolveSnapshot(ctx context.Context, client *Client, name string, since time.Time) (*Snapshot, error) {
+	interval := waiter.Interval
+
+	for {
+		snapshots, err := client.Snapshot.ListWithContext(ctx)
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, NewSnapshotWaitTimeoutError(name, "", ctx.Err())
+			}
+
+			return nil, err
+		}
+
+		if snapshot := findSnapshot(*snapshots, name, since.Add(-snapshotClockSkew)); snapshot != nil {
+			snapshot.SetClient(client)
+
+			return snapshot, waiter.WaitForSnapshot(ctx, snapshot)
+		}
+
+		if err := sleepWithContext(ctx, interval); err != nil {
+			return nil, NewSnapshotWaitTimeoutError(name, "", err)
+		}
+
+		interval = waiter.next(interval)
+	}
+}
+
+// WaitForSnapshot refreshes the snapshot until it is done. It returns a SnapshotFailedError when the snapshot failed
+// and a SnapshotWaitTimeoutError describing the last observed status when the context is done first.
+func (waiter Waiter) WaitForSnapshot(ctx context.Context, snapshot *Snapshot) error {
+	if snapshot.Id == 0 {
+		return NewSnapshotNotCreatedError()
+	}
+
+	interval := waiter.Interval
+
+	for !snapshot.Status.IsFinal() {
+		if err := sleepWithContext(ctx, interval); err != nil {
+			return NewSnapshotWaitTimeoutError(snapshot.Name, snapshot.Status, err)
+		}
+
+		interval = waiter.next(interval)
+
+		if err := snapshot.RefreshWithContext(ctx); err != nil {
+			if ctx.Err() != nil {
+				return NewSnapshotWaitTimeoutError(snapshot.Name, snapshot.Status, ctx.Err())
+			}
+
+			return err
+		}
+	}
+
+	if snapshot.Status.IsFailed() {
+		return NewSnapshotFailedError(snapshot.Name, snapshot.Status)
+	}
+
+	return nil
+}
+
+func findSnapshot(snapshots []Snapshot, name string, since time.Time) *Snapshot {
+	var found *Snapshot
+
+	for i := range snapshots {
+		snapshot := &snapshots[i]
+
+		if snapshot.Name != name || snapshot.Created.Before(since) {
+			continue
+		}
+
+		if found == nil || snapshot.Created.After(found.Created) {
+			found = snapshot
+		}
+	}
+
+	return found
+}
+
+func (waiter Waiter) next(interval time.Duration) time.Duration {
+	if waiter.Multiplier > 1 {
+		interval = time.Duration(float64(interval) * waiter.Multiplier)
+	}
+
+	if waiter.MaxInterval > 0 && interval > waiter.MaxInterval {
+		interval = waiter.MaxInterval
+	}
+
+	return interval
+}
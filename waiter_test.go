@@ -0,0 +1,190 @@
+package go_tilaa_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	go_tilaa "github.com/pascal-splotches/go-tilaa"
+	"github.com/pascal-splotches/go-tilaa/tilaatest"
+)
+
+var testWaiter = go_tilaa.Waiter{Interval: 5 * time.Millisecond, MaxInterval: 5 * time.Millisecond, Multiplier: 1}
+
+// waitWhileChangingStatus waits for the machine to run while the server moves it through the given statuses.
+func waitWhileChangingStatus(t *testing.T, initial go_tilaa.VirtualMachineStatus, statuses ...go_tilaa.VirtualMachineStatus) error {
+	server := tilaatest.NewServer()
+	defer server.Close()
+
+	client, err := server.Client()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	machineId := server.AddVirtualMachine(go_tilaa.VirtualMachine{Name: "web1", Status: initial})
+	machine, err := client.VirtualMachine.View(machineId)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for _, status := range statuses {
+			time.Sleep(20 * time.Millisecond)
+			server.SetStatus(machineId, status)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	return testWaiter.WaitUntilRunning(ctx, machine)
+}
+
+func TestWaitForStatusWaitsOutLeftoverFailure(t *testing.T) {
+	err := waitWhileChangingStatus(t, go_tilaa.VirtualMachineStatusResize_Failed,
+		go_tilaa.VirtualMachineStatusStarting, go_tilaa.VirtualMachineStatusRunning)
+
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWaitForStatusFailsOnNewFailure(t *testing.T) {
+	tests := map[string][]go_tilaa.VirtualMachineStatus{
+		"after leftover": {go_tilaa.VirtualMachineStatusResize_Failed, go_tilaa.VirtualMachineStatusResizing, go_tilaa.VirtualMachineStatusResize_Failed},
+		"other failure":  {go_tilaa.VirtualMachineStatusResize_Failed, go_tilaa.VirtualMachineStatusMigrate_Failed},
+		"from stopped":   {go_tilaa.VirtualMachineStatusStopped, go_tilaa.VirtualMachineStatusResizing, go_tilaa.VirtualMachineStatusResize_Failed},
+		"terminal":       {go_tilaa.VirtualMachineStatusCreateFailed},
+	}
+
+	for name, statuses := range tests {
+		err := waitWhileChangingStatus(t, statuses[0], statuses[1:]...)
+
+		var failed *go_tilaa.VirtualMachineFailedError
+
+		if !errors.As(err, &failed) {
+			t.Errorf("%s: expected a VirtualMachineFailedError, got %v", name, err)
+		}
+	}
+}
+
+func TestWaitForStatusFailsOnFailureSeenFirst(t *testing.T) {
+	server := tilaatest.NewServer()
+	defer server.Close()
+
+	client, err := server.Client()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	running := server.AddVirtualMachine(go_tilaa.VirtualMachine{Name: "web1"})
+	stopped := server.AddVirtualMachine(go_tilaa.VirtualMachine{Name: "web2", Status: go_tilaa.VirtualMachineStatusStopped})
+
+	machine, err := client.VirtualMachine.ViewWithContext(ctx, running)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.SetStatus(running, go_tilaa.VirtualMachineStatusResize_Failed)
+
+	var failed *go_tilaa.VirtualMachineFailedError
+
+	if err := testWaiter.WaitUntilRunning(ctx, machine); !errors.As(err, &failed) {
+		t.Errorf("running: expected a VirtualMachineFailedError, got %v", err)
+	}
+
+	machine, err = client.VirtualMachine.ViewWithContext(ctx, stopped)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.VirtualMachine.RunTaskWithContext(ctx, go_tilaa.TaskStart, machine); err != nil {
+		t.Fatal(err)
+	}
+
+	server.SetStatus(stopped, go_tilaa.VirtualMachineStatusResize_Failed)
+
+	if err := testWaiter.WaitUntilRunning(ctx, machine); !errors.As(err, &failed) {
+		t.Errorf("after start: expected a VirtualMachineFailedError, got %v", err)
+	}
+}
+
+func TestWaitForStatusAfterTasks(t *testing.T) {
+	server := tilaatest.NewServer(tilaatest.WithTransitionDelay(20 * time.Millisecond))
+	defer server.Close()
+
+	client, err := server.Client()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	machineId := server.AddVirtualMachine(go_tilaa.VirtualMachine{Name: "web1"})
+	machine, err := client.VirtualMachine.ViewWithContext(ctx, machineId)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, task := range []struct {
+		name   string
+		status go_tilaa.VirtualMachineStatus
+	}{
+		{go_tilaa.TaskStop, go_tilaa.VirtualMachineStatusStopped},
+		{go_tilaa.TaskStart, go_tilaa.VirtualMachineStatusRunning},
+		{go_tilaa.TaskRestart, go_tilaa.VirtualMachineStatusRunning},
+	} {
+		if _, err := client.VirtualMachine.RunTaskWithContext(ctx, task.name, machine); err != nil {
+			t.Fatalf("%s: %v", task.name, err)
+		}
+
+		if err := testWaiter.WaitForStatus(ctx, machine, task.status); err != nil {
+			t.Fatalf("waiting after %s: %v", task.name, err)
+		}
+	}
+
+	server.SetStatus(machineId, go_tilaa.VirtualMachineStatusCreateFailed)
+
+	if err := testWaiter.WaitUntilRunning(ctx, machine); err == nil {
+		t.Error("expected waiting on a failed machine to fail")
+	}
+}
+
+func TestWaitUntilRunningTimesOut(t *testing.T) {
+	server := tilaatest.NewServer()
+	defer server.Close()
+
+	client, err := server.Client()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	machineId := server.AddVirtualMachine(go_tilaa.VirtualMachine{Name: "web1", Status: go_tilaa.VirtualMachineStatusStopped})
+	machine, err := client.VirtualMachine.View(machineId)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	var timeoutError *go_tilaa.WaitTimeoutError
+
+	if err := testWaiter.WaitUntilRunning(ctx, machine); !errors.As(err, &timeoutError) {
+		t.Errorf("expected a WaitTimeoutError, got %v", err)
+	}
+}